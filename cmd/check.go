@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"k8spreview/pkg/k8s"
+)
+
+// runCheck implements "k8spreview check": it loads the manifests like the
+// viewer does, prints every document that failed to parse and every
+// reference to a resource that is neither loaded nor allowlisted, and
+// returns the process exit code
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	var in input
+	var allow k8s.Allowlist
+	in.register(fs)
+	fs.Var(&allow, "allow", "Treat references to `Kind/name` or namespace/Kind/name as present, wildcards allowed (can be repeated)")
+	allowFile := fs.String("allowlist", "", "Read allowed references from `file`, one pattern per line")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: k8spreview check [flags] <file|dir|glob> ...")
+		fmt.Fprintln(fs.Output(), "\nReports references to resources missing from the manifests and exits")
+		fmt.Fprintln(fs.Output(), "with status 1 if there are any.")
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *allowFile != "" {
+		patterns, err := k8s.LoadAllowlist(*allowFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		allow = append(allow, patterns...)
+	}
+
+	result, err := in.load(fs.Args())
+	if errors.Is(err, errNoInput) {
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	failed := false
+	for _, docErr := range result.Errors {
+		fmt.Printf("parse error: %v\n", docErr)
+		failed = true
+	}
+
+	g := k8s.BuildGraphWithOptions(result.Resources, in.graphOptions())
+	unresolved := g.Unresolved(allow)
+	k8s.SortEdges(unresolved)
+	for _, e := range unresolved {
+		if res, ok := g.Resource(e.From); ok {
+			fmt.Printf("%s: ", res.Source)
+		}
+		target := e.To.String()
+		if e.To.Namespace != "" {
+			target += " in namespace " + e.To.Namespace
+		}
+		fmt.Printf("%s %s missing %s (%s)\n", e.From, e.Type, target, e.Field)
+		failed = true
+	}
+
+	if failed {
+		fmt.Printf("%d unresolved references, %d parse errors\n", len(unresolved), len(result.Errors))
+		return 1
+	}
+	fmt.Printf("%d resources checked, all references resolved\n", len(result.Resources))
+	return 0
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckLegacyIngress(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "ingress.yaml")
+	content := `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - port: 80
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  annotations:
+    kubernetes.io/ingress.class: nginx
+spec:
+  backend:
+    serviceName: web
+    servicePort: 80
+`
+	if err := os.WriteFile(manifest, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := runCheck([]string{manifest}); code != 0 {
+		t.Errorf("Expected exit code 0 for a legacy-annotated Ingress, got %d", code)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	content += `  tls:
+    - secretName: web-tls
+`
+	if err := os.WriteFile(missing, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := runCheck([]string{missing}); code != 1 {
+		t.Errorf("Expected exit code 1 for a missing TLS Secret, got %d", code)
+	}
+}
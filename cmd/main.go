@@ -1,64 +1,174 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
+	"k8spreview/pkg/helm"
 	"k8spreview/pkg/k8s"
+	"k8spreview/pkg/kustomize"
 	"k8spreview/pkg/ui"
 	"k8spreview/pkg/version"
 )
 
-func main() {
-	versionFlag := flag.Bool("version", false, "Print version information")
-	flag.Parse()
+// stringList is a flag that may be repeated or given comma-separated values
+type stringList []string
 
-	if *versionFlag {
-		fmt.Printf("k8spreview %s\n", version.Version)
-		fmt.Printf("Commit: %s\n", version.Commit)
-		fmt.Printf("Build Date: %s\n", version.Date)
-		os.Exit(0)
-	}
+func (s *stringList) String() string { return strings.Join(*s, ",") }
 
-	args := flag.Args()
-	var resources []k8s.Resource
-	if len(args) == 0 {
-		fi, err := os.Stdin.Stat()
+func (s *stringList) Set(v string) error {
+	*s = append(*s, strings.Split(v, ",")...)
+	return nil
+}
+
+// valueList is a flag that may be repeated. Values are kept unchanged, as
+// helm --set does, since strvals handles commas, escapes and {a,b} lists.
+type valueList []string
+
+func (v *valueList) String() string { return strings.Join(*v, ",") }
+
+func (v *valueList) Set(value string) error {
+	*v = append(*v, value)
+	return nil
+}
+
+// errNoInput is returned by input.load when there is nothing to read
+var errNoInput = errors.New("no input")
+
+// input holds the flags that select which manifests are loaded, shared by
+// the viewer and the check command
+type input struct {
+	findOpts     k8s.FindOptions
+	helmOpts     helm.Options
+	helmChart    string
+	kustomizeDir string
+	namespace    string
+}
+
+// register adds the input flags to fs
+func (in *input) register(fs *flag.FlagSet) {
+	fs.BoolVar(&in.findOpts.Recursive, "R", false, "Read directories recursively")
+	fs.Var((*stringList)(&in.findOpts.Include), "include", "Only read files matching `glob` when expanding directories and globs (default *.yaml,*.yml,*.json)")
+	fs.Var((*stringList)(&in.findOpts.Exclude), "exclude", "Skip files and directories matching `glob`")
+	fs.BoolVar(&in.findOpts.AllFiles, "all-files", false, "Do not skip non-manifest files such as Chart.yaml and kustomization.yaml")
+	fs.StringVar(&in.helmChart, "helm", "", "Render the Helm chart in `dir` and preview the result")
+	fs.Var((*valueList)(&in.helmOpts.ValuesFiles), "f", "Helm values `file` (can be repeated)")
+	fs.Var((*valueList)(&in.helmOpts.Values), "set", "Set Helm `key=value` (can be repeated)")
+	fs.Var((*valueList)(&in.helmOpts.StringValues), "set-string", "Set Helm STRING `key=value` (can be repeated)")
+	fs.StringVar(&in.helmOpts.ReleaseName, "release-name", "", "Helm release `name` (default release-name)")
+	fs.StringVar(&in.helmOpts.Namespace, "release-namespace", "", "Helm release `namespace` (default default)")
+	fs.StringVar(&in.namespace, "namespace", "", "Namespace assumed for resources without one (default the Helm release namespace or default)")
+	fs.StringVar(&in.kustomizeDir, "kustomize", "", "Build the kustomization in `dir` and preview the result")
+}
+
+// load reads the manifests selected by the flags and args. Piped stdin is
+// read when nothing else is given; otherwise errNoInput is returned.
+func (in *input) load(args []string) (*k8s.Result, error) {
+	result := &k8s.Result{}
+	if in.helmChart != "" {
+		rs, err := helm.Load(in.helmChart, in.helmOpts)
 		if err != nil {
-			fmt.Printf("Error reading stdin: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("rendering Helm chart %s: %w", in.helmChart, err)
 		}
-		// If data is being piped in, read from stdin
-		if (fi.Mode() & os.ModeCharDevice) == 0 {
-			resources, err = k8s.Parse(os.Stdin)
-			if err != nil {
-				fmt.Printf("Error parsing YAML from stdin: %v\n", err)
-				os.Exit(1)
-			}
-		} else {
-			fmt.Println("Usage: k8spreview <file1.yaml> [file2.yaml ...]")
-			fmt.Println("       cat file.yaml | k8spreview -")
-			os.Exit(1)
+		result.Add(rs)
+	}
+	if in.kustomizeDir != "" {
+		rs, err := kustomize.Load(in.kustomizeDir)
+		if err != nil {
+			return nil, fmt.Errorf("building kustomization %s: %w", in.kustomizeDir, err)
 		}
-	} else {
-		for _, path := range args {
-			var rs []k8s.Resource
-			var err error
+		result.Add(rs)
+	}
+	switch {
+	case len(args) > 0:
+		paths, err := k8s.FindManifests(args, in.findOpts)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no manifest files found in %s", strings.Join(args, " "))
+		}
+		for _, path := range paths {
+			var rs *k8s.Result
 			if path == "-" {
-				rs, err = k8s.Parse(os.Stdin)
+				rs, err = k8s.Load(os.Stdin, "")
 			} else {
-				rs, err = k8s.ParseFromFile(path)
+				rs, err = k8s.LoadFile(path)
 			}
 			if err != nil {
-				fmt.Printf("Error parsing YAML from %s: %v\n", path, err)
-				os.Exit(1)
+				return nil, fmt.Errorf("reading manifests from %s: %w", path, err)
 			}
-			resources = append(resources, rs...)
+			result.Add(rs)
 		}
+	case in.helmChart == "" && in.kustomizeDir == "":
+		fi, err := os.Stdin.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("reading stdin: %w", err)
+		}
+		// If data is being piped in, read from stdin
+		if (fi.Mode() & os.ModeCharDevice) != 0 {
+			return nil, errNoInput
+		}
+		result, err = k8s.Load(os.Stdin, "")
+		if err != nil {
+			return nil, fmt.Errorf("reading manifests from stdin: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// graphOptions returns the options for relating the loaded resources
+func (in *input) graphOptions() k8s.GraphOptions {
+	// Resources without a namespace are installed into the release
+	// namespace by helm install, so relate them there too
+	opts := k8s.GraphOptions{DefaultNamespace: in.namespace}
+	if opts.DefaultNamespace == "" && in.helmChart != "" {
+		opts.DefaultNamespace = in.helmOpts.Namespace
+	}
+	return opts
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+
+	var in input
+	versionFlag := flag.Bool("version", false, "Print version information")
+	in.register(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: k8spreview [flags] <file|dir|glob> ...")
+		fmt.Fprintln(flag.CommandLine.Output(), "       k8spreview -helm <chart> [-f values.yaml] [-set key=value]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       k8spreview -kustomize <dir>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cat file.yaml | k8spreview -")
+		fmt.Fprintln(flag.CommandLine.Output(), "       k8spreview check [flags] <file|dir|glob> ...")
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *versionFlag {
+		fmt.Printf("k8spreview %s\n", version.Version)
+		fmt.Printf("Commit: %s\n", version.Commit)
+		fmt.Printf("Build Date: %s\n", version.Date)
+		os.Exit(0)
+	}
+
+	result, err := in.load(flag.Args())
+	if errors.Is(err, errNoInput) {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	if err := ui.RunWithResources(resources); err != nil {
+	// Broken documents are shown in the UI rather than aborting
+	if err := ui.RunWithOptions(result, in.graphOptions()); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
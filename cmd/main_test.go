@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/strvals"
+)
+
+func TestHelmValueFlags(t *testing.T) {
+	var in input
+	fs := flag.NewFlagSet("k8spreview", flag.ContinueOnError)
+	in.register(fs)
+	args := []string{"-set", "list={a,b}", "-set", `name=b\,c`, "-set-string", "tag=1.0", "-include", "*.yaml,*.json"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if want := []string{"list={a,b}", `name=b\,c`}; !reflect.DeepEqual(in.helmOpts.Values, want) {
+		t.Errorf("Expected -set values %q, got %q", want, in.helmOpts.Values)
+	}
+	vals := map[string]interface{}{}
+	for _, v := range in.helmOpts.Values {
+		if err := strvals.ParseInto(v, vals); err != nil {
+			t.Fatalf("ParseInto(%q) failed: %v", v, err)
+		}
+	}
+	want := map[string]interface{}{"list": []interface{}{"a", "b"}, "name": "b,c"}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Expected values %v, got %v", want, vals)
+	}
+	if want := []string{"*.yaml", "*.json"}; !reflect.DeepEqual(in.findOpts.Include, want) {
+		t.Errorf("Expected -include to be split into %q, got %q", want, in.findOpts.Include)
+	}
+}
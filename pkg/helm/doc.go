@@ -0,0 +1,24 @@
+/*
+Package helm renders Helm charts locally so they can be previewed without a
+cluster or a separate helm template step.
+
+Rendering uses the Helm SDK and is fully offline: chart dependencies must be
+vendored in the chart's charts/ directory, and the lookup template function
+returns empty results. The rendered stream matches helm template output, with
+each document preceded by a "# Source:" comment, which k8s.Load records as
+the resource's Source.Template.
+
+Example Usage:
+
+	result, err := helm.Load("./chart", helm.Options{
+	    ValuesFiles: []string{"values-prod.yaml"},
+	    Values:      []string{"image.tag=1.2.3"},
+	})
+	if err != nil {
+	    log.Fatal(err)
+	}
+	for _, res := range result.Resources {
+	    fmt.Printf("%s/%s from %s\n", res.Kind, res.Metadata.Name, res.Source.Template)
+	}
+*/
+package helm
@@ -0,0 +1,179 @@
+package helm
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/strvals"
+
+	"k8spreview/pkg/k8s"
+)
+
+// Options configures how a chart is rendered
+type Options struct {
+	ReleaseName  string   // Defaults to "release-name", like helm template
+	Namespace    string   // Defaults to "default"
+	ValuesFiles  []string // Values files, later files take precedence (-f)
+	Values       []string // key=value overrides (--set)
+	StringValues []string // key=value overrides forced to strings (--set-string)
+}
+
+// Render renders the templates of the chart at chartPath and returns the
+// resulting manifest stream, in the same shape as helm template: every
+// document is preceded by a "# Source:" comment naming its template.
+//
+// Rendering is fully offline. Dependencies must already be vendored in the
+// chart's charts/ directory and lookup calls return empty results.
+func Render(chartPath string, opts Options) (string, error) {
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return "", fmt.Errorf("error loading chart: %w", err)
+	}
+	if err := checkDependencies(chrt); err != nil {
+		return "", err
+	}
+
+	vals, err := mergeValues(opts)
+	if err != nil {
+		return "", err
+	}
+	if err := chartutil.ProcessDependenciesWithMerge(chrt, vals); err != nil {
+		return "", fmt.Errorf("error processing chart dependencies: %w", err)
+	}
+
+	releaseOpts := chartutil.ReleaseOptions{
+		Name:      opts.ReleaseName,
+		Namespace: opts.Namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	if releaseOpts.Name == "" {
+		releaseOpts.Name = "release-name"
+	}
+	if releaseOpts.Namespace == "" {
+		releaseOpts.Namespace = "default"
+	}
+	renderVals, err := chartutil.ToRenderValues(chrt, vals, releaseOpts, chartutil.DefaultCapabilities)
+	if err != nil {
+		return "", fmt.Errorf("error preparing chart values: %w", err)
+	}
+	rendered, err := engine.Render(chrt, renderVals)
+	if err != nil {
+		return "", fmt.Errorf("error rendering chart: %w", err)
+	}
+
+	var sb strings.Builder
+	for _, crd := range chrt.CRDObjects() {
+		writeManifests(&sb, crd.Filename, string(crd.File.Data))
+	}
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if path.Base(name) == "NOTES.txt" {
+			continue
+		}
+		writeManifests(&sb, name, rendered[name])
+	}
+	return sb.String(), nil
+}
+
+// Load renders the chart at chartPath and parses the result. Each resource
+// records chartPath as its file and the template it came from.
+func Load(chartPath string, opts Options) (*k8s.Result, error) {
+	manifest, err := Render(chartPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return k8s.Load(strings.NewReader(manifest), chartPath)
+}
+
+// writeManifests writes each document of a rendered template, preceded by a
+// "# Source:" comment
+func writeManifests(sb *strings.Builder, name, content string) {
+	manifests := releaseutil.SplitManifests(content)
+	keys := make([]string, 0, len(manifests))
+	for k := range manifests {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+	for _, k := range keys {
+		if strings.TrimSpace(manifests[k]) == "" {
+			continue
+		}
+		fmt.Fprintf(sb, "---\n# Source: %s\n%s\n", name, manifests[k])
+	}
+}
+
+// checkDependencies ensures every dependency declared in Chart.yaml is
+// present in charts/, since nothing is fetched from a repository
+func checkDependencies(chrt *chart.Chart) error {
+	var missing []string
+	for _, dep := range chrt.Metadata.Dependencies {
+		found := false
+		for _, c := range chrt.Dependencies() {
+			if c.Name() == dep.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, dep.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("chart dependencies missing from charts/ directory (run helm dependency build): %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// mergeValues combines values files and --set style overrides, in the same
+// order of precedence as helm
+func mergeValues(opts Options) (map[string]interface{}, error) {
+	base := map[string]interface{}{}
+	for _, file := range opts.ValuesFiles {
+		vals, err := chartutil.ReadValuesFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading values file %s: %w", file, err)
+		}
+		base = mergeMaps(base, vals)
+	}
+	for _, v := range opts.Values {
+		if err := strvals.ParseInto(v, base); err != nil {
+			return nil, fmt.Errorf("error parsing --set %s: %w", v, err)
+		}
+	}
+	for _, v := range opts.StringValues {
+		if err := strvals.ParseIntoString(v, base); err != nil {
+			return nil, fmt.Errorf("error parsing --set-string %s: %w", v, err)
+		}
+	}
+	return base, nil
+}
+
+// mergeMaps merges b into a, recursing into nested maps
+func mergeMaps(a, b map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		if v, ok := v.(map[string]interface{}); ok {
+			if bv, ok := out[k].(map[string]interface{}); ok {
+				out[k] = mergeMaps(bv, v)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
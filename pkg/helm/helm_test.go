@@ -0,0 +1,122 @@
+package helm_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8spreview/pkg/helm"
+)
+
+func writeChart(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "web")
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: web
+version: 0.1.0
+`,
+		"values.yaml": `image:
+  repository: nginx
+  tag: latest
+service:
+  enabled: true
+`,
+		"templates/_helpers.tpl": `{{- define "web.fullname" -}}
+{{ .Release.Name }}-{{ .Chart.Name }}
+{{- end -}}
+`,
+		"templates/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "web.fullname" . }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+`,
+		"templates/service.yaml": `{{- if .Values.service.enabled }}
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ include "web.fullname" . }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ include "web.fullname" . }}-headless
+{{- end }}
+`,
+		"templates/NOTES.txt": `Thanks for installing {{ .Chart.Name }}`,
+	})
+	valuesFile := filepath.Join(t.TempDir(), "values-prod.yaml")
+	if err := os.WriteFile(valuesFile, []byte("image:\n  tag: \"1.0\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := helm.Load(dir, helm.Options{
+		ReleaseName: "prod",
+		Namespace:   "web",
+		ValuesFiles: []string{valuesFile},
+		Values:      []string{"image.repository=registry.local/nginx"},
+	})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(result.Errors) != 0 || len(result.Others) != 0 {
+		t.Fatalf("Unexpected errors %v or other documents %v", result.Errors, result.Others)
+	}
+	if len(result.Resources) != 3 {
+		t.Fatalf("Expected 3 resources, got %d", len(result.Resources))
+	}
+
+	deploy := result.Resources[0]
+	if deploy.Kind != "Deployment" || deploy.Metadata.Name != "prod-web" || deploy.Metadata.Namespace != "web" {
+		t.Errorf("Unexpected deployment: %s/%s in %s", deploy.Kind, deploy.Metadata.Name, deploy.Metadata.Namespace)
+	}
+	if deploy.Source.Template != "web/templates/deployment.yaml" || deploy.Source.File != dir {
+		t.Errorf("Unexpected source: %v", deploy.Source)
+	}
+	containers, _ := deploy.Get("spec", "template", "spec", "containers")
+	image := containers.([]interface{})[0].(map[string]interface{})["image"]
+	if image != "registry.local/nginx:1.0" {
+		t.Errorf("Expected values to be merged, got image %v", image)
+	}
+
+	headless := result.Resources[2]
+	if headless.Metadata.Name != "prod-web-headless" || headless.Source.Template != "web/templates/service.yaml" {
+		t.Errorf("Unexpected second service %s from %v", headless.Metadata.Name, headless.Source)
+	}
+}
+
+func TestRenderMissingDependency(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: web
+version: 0.1.0
+dependencies:
+  - name: redis
+    version: 1.0.0
+    repository: https://charts.example.com
+`,
+	})
+	if _, err := helm.Render(dir, helm.Options{}); err == nil {
+		t.Error("Expected an error for a dependency missing from charts/")
+	}
+}
@@ -0,0 +1,118 @@
+package k8s
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Allowlist holds patterns for resources that are known to exist in the
+// cluster even though they are not part of the loaded manifests. A pattern
+// is either Kind/name, matching in any namespace, or namespace/Kind/name.
+// Each part may use path.Match wildcards, e.g. "Secret/registry-*" or
+// "kube-system/ConfigMap/*".
+type Allowlist []string
+
+// ParseAllowlist reads an allowlist with one pattern per line. Blank lines
+// and lines starting with # are ignored.
+func ParseAllowlist(r io.Reader) (Allowlist, error) {
+	var allow Allowlist
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := validatePattern(line); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		allow = append(allow, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading allowlist: %w", err)
+	}
+	return allow, nil
+}
+
+// LoadAllowlist reads an allowlist file, see ParseAllowlist
+func LoadAllowlist(filename string) (Allowlist, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening allowlist: %w", err)
+	}
+	defer f.Close()
+	allow, err := ParseAllowlist(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return allow, nil
+}
+
+// validatePattern checks that a pattern has two or three well-formed parts
+func validatePattern(pattern string) error {
+	parts := strings.Split(pattern, "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return fmt.Errorf("invalid pattern %q: expected Kind/name or namespace/Kind/name", pattern)
+	}
+	for _, part := range parts {
+		if _, err := path.Match(part, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// String implements flag.Value
+func (a *Allowlist) String() string {
+	return strings.Join(*a, ",")
+}
+
+// Set implements flag.Value, adding one or more comma-separated patterns
+func (a *Allowlist) Set(value string) error {
+	for _, pattern := range strings.Split(value, ",") {
+		if err := validatePattern(pattern); err != nil {
+			return err
+		}
+		*a = append(*a, pattern)
+	}
+	return nil
+}
+
+// Allows reports whether n matches any pattern of the allowlist
+func (a Allowlist) Allows(n Node) bool {
+	for _, pattern := range a {
+		parts := strings.Split(pattern, "/")
+		want := []string{n.Kind, n.Name}
+		if len(parts) == 3 {
+			want = []string{n.Namespace, n.Kind, n.Name}
+		}
+		if len(parts) != len(want) {
+			continue
+		}
+		matched := true
+		for i := range parts {
+			if ok, _ := path.Match(parts[i], want[i]); !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Unresolved returns the dangling edges of g whose target is not allowed
+func (g *Graph) Unresolved(allow Allowlist) []Edge {
+	var unresolved []Edge
+	for _, e := range g.Dangling() {
+		if !allow.Allows(e.To) {
+			unresolved = append(unresolved, e)
+		}
+	}
+	return unresolved
+}
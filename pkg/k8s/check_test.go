@@ -0,0 +1,127 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestDanglingReferences(t *testing.T) {
+	content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  template:
+    spec:
+      volumes:
+        - name: config
+          configMap:
+            name: app-config
+        - name: tls
+          secret:
+            secretName: web-tls
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: web-tls
+  namespace: shop
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+
+	dangling := g.Dangling()
+	if len(dangling) != 1 || dangling[0].To.String() != "ConfigMap/app-config" {
+		t.Fatalf("Expected ConfigMap/app-config to be dangling, got %+v", dangling)
+	}
+	relations := strings.Join(resources[0].FindRelatedResources(resources), "\n")
+	if !strings.Contains(relations, "→ Mounts ConfigMap/app-config (missing)") {
+		t.Errorf("Expected missing ConfigMap in relations, got:\n%s", relations)
+	}
+	if strings.Contains(relations, "Secret/web-tls (missing)") {
+		t.Errorf("Expected Secret/web-tls to resolve, got:\n%s", relations)
+	}
+
+	tests := []struct {
+		allow k8s.Allowlist
+		want  int
+	}{
+		{nil, 1},
+		{k8s.Allowlist{"ConfigMap/app-config"}, 0},
+		{k8s.Allowlist{"ConfigMap/app-*"}, 0},
+		{k8s.Allowlist{"shop/ConfigMap/*"}, 0},
+		{k8s.Allowlist{"other/ConfigMap/app-config"}, 1},
+		{k8s.Allowlist{"Secret/app-config"}, 1},
+	}
+	for _, tt := range tests {
+		if got := len(g.Unresolved(tt.allow)); got != tt.want {
+			t.Errorf("Allowlist %v: expected %d unresolved, got %d", tt.allow, tt.want, got)
+		}
+	}
+}
+
+func TestImplicitResources(t *testing.T) {
+	content := `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: shop
+spec:
+  serviceAccountName: default
+  volumes:
+    - name: ca
+      projected:
+        sources:
+          - configMap:
+              name: kube-root-ca.crt
+    - name: config
+      configMap:
+        name: default
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+
+	unresolved := g.Unresolved(nil)
+	if len(unresolved) != 1 || unresolved[0].To.String() != "ConfigMap/default" {
+		t.Errorf("Expected only ConfigMap/default to be unresolved, got %+v", unresolved)
+	}
+	if got := len(g.Out(g.Nodes()[0])); got != 3 {
+		t.Errorf("Expected 3 edges, got %d", got)
+	}
+}
+
+func TestParseAllowlist(t *testing.T) {
+	allow, err := k8s.ParseAllowlist(strings.NewReader(`
+# Created by the platform team
+Secret/registry-credentials
+kube-system/ConfigMap/*
+`))
+	if err != nil {
+		t.Fatalf("ParseAllowlist failed: %v", err)
+	}
+	if len(allow) != 2 {
+		t.Fatalf("Expected 2 patterns, got %v", allow)
+	}
+	if !allow.Allows(k8s.Node{Kind: "ConfigMap", Namespace: "kube-system", Name: "coredns"}) {
+		t.Error("Expected kube-system ConfigMaps to be allowed")
+	}
+
+	for _, bad := range []string{"registry-credentials", "a/b/c/d", "Secret/[x"} {
+		if _, err := k8s.ParseAllowlist(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+		var a k8s.Allowlist
+		if err := a.Set(bad); err == nil {
+			t.Errorf("Expected Set to reject %q", bad)
+		}
+	}
+}
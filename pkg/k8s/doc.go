@@ -3,14 +3,35 @@ Package k8s provides functionality for parsing and analyzing Kubernetes YAML res
 
 The package includes support for:
   - Parsing multi-document YAML files containing Kubernetes resources
+  - Parsing JSON input: a single object, an array of objects or JSON lines
+  - Expanding List wrappers (kind: List, DeploymentList, ...) into their items
   - Analyzing relationships between resources (e.g., Service-Deployment connections)
   - Finding references between resources (ConfigMaps, Secrets, etc.)
   - Exporting individual resources to YAML files
+  - Discovering manifest files in directories and "**" globs (FindManifests)
+
+Parsing is lenient: a malformed document does not abort the stream. Load and
+LoadFile return a Result holding the valid resources together with a
+DocumentError (file, document index, line and raw text) for each broken
+document. Parse and ParseFromFile return the same resources and report the
+failures as a ParseErrors error.
+
+Every Resource keeps the complete decoded document in Resource.Object, so
+fields the package does not model explicitly (annotations, status, rules,
+webhooks, ...) are preserved in the detail view and on export. Use
+Resource.Get to read arbitrary fields:
+
+	rules, ok := res.Get("rules")
+
+Resource.Raw keeps the original document text. Resource.Text and ExportAs
+render either that text verbatim (Original) or the re-encoded object
+(Normalized).
 
 Resource Types:
   - Services
-  - Deployments
-  - StatefulSets
+  - Deployments, StatefulSets, DaemonSets, ReplicaSets and
+    ReplicationControllers
+  - Jobs and CronJobs
   - Pods
   - ConfigMaps
   - Secrets
@@ -19,11 +40,78 @@ Resource Types:
 
 Relationship Detection:
 The package can detect various relationships between resources:
-  - Service selector matching Deployment/StatefulSet labels
-  - ConfigMap and Secret usage in volumes
-  - Secret references in environment variables
-  - Ingress backend service references
+  - Service selector matching the pod template labels of any workload
+  - Workload, NetworkPolicy and PodDisruptionBudget label selectors
+    (matchLabels and matchExpressions) matching Pods
+  - ConfigMap, Secret and projected volumes, PersistentVolumeClaim volumes
+    and CSI nodePublishSecretRef in any pod template, including a
+    CronJob's spec.jobTemplate.spec.template
+  - env valueFrom and envFrom of init, regular and ephemeral containers,
+    recording the container and key
+  - imagePullSecrets and serviceAccountName (see PodSpecRefs)
+  - PersistentVolumeClaim and PersistentVolume binding (volumeName and
+    claimRef) and the StorageClass requested by claims, volumes and
+    StatefulSet volumeClaimTemplates, falling back to the default class
+  - RoleBinding and ClusterRoleBinding roleRef and ServiceAccount subjects,
+    and ClusterRoles aggregated through aggregationRule selectors
+  - Ingress backends (rules, defaultBackend, resource backends and the
+    legacy serviceName/servicePort shape), TLS secrets and IngressClass,
+    warning when the backend Service has no such port
   - HPA scale target references
+  - Gateway API: Gateways to their GatewayClass and certificate Secrets,
+    and HTTPRoute, GRPCRoute, TCPRoute, TLSRoute and UDPRoute parentRefs
+    and backendRefs. Cross-namespace references must be allowed by a
+    loaded ReferenceGrant, and parent Gateways must accept the route's
+    namespace in allowedRoutes.
+  - metadata.ownerReferences, e.g. a ReplicaSet owned by a Deployment or a
+    Job owned by a CronJob, warning when the owner's uid differs
+
+Relationships are computed once for a set of resources with BuildGraph. A
+Graph holds typed Nodes (group, version, kind, namespace and name) and Edges
+(selects, mounts, references, routes, scales, binds, aggregates, attaches,
+permits, owned-by) that record the field path that created them, and can be
+queried by outgoing or incoming edges. Graph.OwnerTree nests the resources
+beneath their owners.
+
+References and selectors only match resources in the same namespace.
+Resources are told apart by API group, so a Knative Service and a core
+Service of the same name are both kept; a reference naming a group only
+matches that group, and one without prefers the core group.
+Namespaced resources without metadata.namespace are placed in
+GraphOptions.DefaultNamespace ("default" unless set), and cluster-scoped
+kinds such as Namespace, ClusterRole or StorageClass have no namespace
+(see IsClusterScoped). Use BuildGraphWithOptions to change the default.
+
+Edges whose target is not among the loaded resources are marked Dangling,
+except for the default ServiceAccount and the kube-root-ca.crt ConfigMap
+that Kubernetes creates in every namespace, and for GatewayClasses,
+IngressClasses and StorageClasses, which are installed with the cluster or
+its controllers (see IsImplicit).
+Graph.Unresolved filters them through an Allowlist of Kind/name or
+namespace/Kind/name patterns for resources known to exist in the cluster.
+
+Graph.Permissions computes the effective permissions of a ServiceAccount
+from the loaded bindings: every resource and verb it is granted, per
+namespace, including rules inherited through aggregated ClusterRoles and
+bindings to the system:serviceaccounts groups.
+
+Graph.Network evaluates NetworkPolicies (podSelector, namespaceSelector,
+ingress and egress rules and ports) against the loaded workloads and
+reports which pairs may communicate and on which ports.
+
+Label selectors are evaluated with full Kubernetes semantics (In, NotIn,
+Exists, DoesNotExist). Problems found along the way, such as a workload whose
+selector does not match its own pod template, are recorded as Issues. Keys
+used through configMapKeyRef, secretKeyRef and volume items are checked
+against the data and binaryData of loaded ConfigMaps and the data and
+stringData of loaded Secrets; a missing key is a warning unless the
+reference is optional.
+
+Graph.ServicePorts maps each port of a Service to the container port its
+targetPort resolves to on every selected workload. A targetPort no selected
+pod exposes is an Issue: an error for a named port, which leaves the Service
+without endpoints, and a warning for a number, since declaring container
+ports is optional.
 
 Example Usage:
 
@@ -34,10 +122,10 @@ Example Usage:
 	}
 
 	// Find relationships for each resource
-	for _, res := range resources {
-	    relations := res.FindRelatedResources(resources)
-	    for _, rel := range relations {
-	        fmt.Printf("%s/%s: %s\n", res.Kind, res.Metadata.Name, rel)
+	g := k8s.BuildGraph(resources)
+	for _, n := range g.Nodes() {
+	    for _, e := range g.Out(n) {
+	        fmt.Printf("%s %s %s (%s)\n", n, e.Type.Verb(), e.To, e.Field)
 	    }
 	}
 */
@@ -0,0 +1,81 @@
+package k8s
+
+import "fmt"
+
+// field is a value within a resource together with the path leading to it,
+// such as spec.template.spec.volumes[0].configMap.name. Accessors on a
+// missing or mistyped value return empty results, which keeps walking deeply
+// nested objects free of type assertions.
+type field struct {
+	path  string
+	value interface{}
+}
+
+// root returns the full object as a field. Resources built in code without
+// a decoded Object fall back to their typed fields.
+func (r Resource) root() field {
+	if r.Object != nil {
+		return field{value: r.Object}
+	}
+	obj := map[string]interface{}{
+		"apiVersion": r.APIVersion,
+		"kind":       r.Kind,
+		"metadata": map[string]interface{}{
+			"name":      r.Metadata.Name,
+			"namespace": r.Metadata.Namespace,
+		},
+	}
+	if r.Spec != nil {
+		obj["spec"] = r.Spec
+	}
+	if r.Data != nil {
+		obj["data"] = r.Data
+	}
+	return field{value: obj}
+}
+
+// get returns the value of a map key
+func (f field) get(key string) field {
+	p := key
+	if f.path != "" {
+		p = f.path + "." + key
+	}
+	m, _ := f.value.(map[string]interface{})
+	return field{path: p, value: m[key]}
+}
+
+// items returns the elements of a list
+func (f field) items() []field {
+	list, _ := f.value.([]interface{})
+	fields := make([]field, len(list))
+	for i, v := range list {
+		fields[i] = field{path: fmt.Sprintf("%s[%d]", f.path, i), value: v}
+	}
+	return fields
+}
+
+// str returns a string value, or "" if the value is not a string
+func (f field) str() string {
+	s, _ := f.value.(string)
+	return s
+}
+
+// strings returns the string elements of a list
+func (f field) strings() []string {
+	var values []string
+	for _, item := range f.items() {
+		if s, ok := item.value.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// stringMap returns the string values of a map
+func (f field) stringMap() map[string]string {
+	m, _ := f.value.(map[string]interface{})
+	if m == nil {
+		return nil
+	}
+	return convertToStringMap(m)
+}
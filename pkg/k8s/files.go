@@ -0,0 +1,258 @@
+package k8s
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FindOptions controls how FindManifests expands command line arguments
+type FindOptions struct {
+	// Recursive walks directories recursively instead of reading only the
+	// files directly inside them
+	Recursive bool
+	// Include restricts discovered files to those matching one of these
+	// globs. Defaults to *.yaml, *.yml and *.json.
+	Include []string
+	// Exclude skips files and directories matching any of these globs
+	Exclude []string
+	// AllFiles disables skipping of known non-manifest files such as
+	// Chart.yaml and kustomization.yaml
+	AllFiles bool
+}
+
+// DefaultInclude lists the globs used when FindOptions.Include is empty
+var DefaultInclude = []string{"*.yaml", "*.yml", "*.json"}
+
+// nonManifestFiles are YAML files commonly found next to manifests that are
+// not Kubernetes objects themselves
+var nonManifestFiles = map[string]bool{
+	"Chart.yaml":              true,
+	"Chart.lock":              true,
+	"values.yaml":             true,
+	"values.schema.json":      true,
+	"kustomization.yaml":      true,
+	"kustomization.yml":       true,
+	"Kustomization":           true,
+	"skaffold.yaml":           true,
+	"package.json":            true,
+	"tsconfig.json":           true,
+	".pre-commit-config.yaml": true,
+	".gitlab-ci.yml":          true,
+}
+
+// FindManifests expands command line arguments into the list of manifest
+// files to read. Arguments may be files, directories or globs; globs are
+// expanded independently of the shell and support "**" to match any number
+// of directories. The special argument "-" (stdin) is passed through.
+//
+// Files named explicitly are always returned. Files discovered through a
+// directory or glob must match the include globs, must not match the exclude
+// globs and must not be a known non-manifest file unless AllFiles is set.
+func FindManifests(args []string, opts FindOptions) ([]string, error) {
+	if len(opts.Include) == 0 {
+		opts.Include = DefaultInclude
+	}
+	var files []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			files = append(files, name)
+		}
+	}
+
+	for _, arg := range args {
+		if arg == "-" {
+			files = append(files, arg)
+			continue
+		}
+		if hasGlobMeta(arg) {
+			matches, err := expandGlob(arg, opts)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
+			}
+			for _, m := range matches {
+				add(m)
+			}
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, fmt.Errorf("error opening file: %w", err)
+		}
+		if !info.IsDir() {
+			add(arg)
+			continue
+		}
+		matches, err := walkDir(arg, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	return files, nil
+}
+
+// walkDir lists the manifest files in dir, descending into subdirectories
+// only when opts.Recursive is set
+func walkDir(dir string, opts FindOptions) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p == dir {
+				return nil
+			}
+			if !opts.Recursive || strings.HasPrefix(d.Name(), ".") || opts.excluded(p) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if opts.wanted(p) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// expandGlob returns the manifest files matching pattern
+func expandGlob(pattern string, opts FindOptions) ([]string, error) {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	root := globRoot(pattern)
+	segments := strings.Split(pattern, "/")
+	recursive := strings.Contains(pattern, "**")
+	var files []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == filepath.FromSlash(root) && os.IsNotExist(err) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		slashed := filepath.ToSlash(p)
+		if d.IsDir() {
+			if d.Name() == ".git" || slashed != root && (strings.HasPrefix(d.Name(), ".") || opts.excluded(p)) {
+				return filepath.SkipDir
+			}
+			// Without "**" only directories leading to a match are entered
+			if dir := strings.Split(slashed, "/"); !recursive && slashed != root &&
+				(len(dir) >= len(segments) || !matchSegments(segments[:len(dir)], dir)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchGlob(pattern, slashed) && opts.wanted(p) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error expanding %s: %w", pattern, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// wanted reports whether a discovered file should be read
+func (o FindOptions) wanted(p string) bool {
+	base := filepath.Base(p)
+	if !o.AllFiles && nonManifestFiles[base] {
+		return false
+	}
+	if o.excluded(p) {
+		return false
+	}
+	return matchAny(o.Include, p)
+}
+
+// excluded reports whether p matches one of the exclude globs
+func (o FindOptions) excluded(p string) bool {
+	return matchAny(o.Exclude, p)
+}
+
+// matchAny matches p against patterns. Patterns without a slash are matched
+// against the base name, others against the whole path.
+func matchAny(patterns []string, p string) bool {
+	slashed := strings.TrimPrefix(filepath.ToSlash(p), "./")
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(slashed)); ok {
+				return true
+			}
+			continue
+		}
+		if matchGlob(pattern, slashed) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated path against a glob pattern in which
+// "**" matches zero or more path segments
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// globRoot returns the longest leading directory of pattern that contains no
+// glob metacharacters
+func globRoot(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	var root []string
+	for _, s := range segments[:len(segments)-1] {
+		if hasGlobMeta(s) {
+			break
+		}
+		root = append(root, s)
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	if len(root) == 1 && root[0] == "" {
+		return "/"
+	}
+	return strings.Join(root, "/")
+}
+
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
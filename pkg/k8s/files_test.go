@@ -0,0 +1,99 @@
+package k8s_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestFindManifests(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"deploy/app.yaml",
+		"deploy/Chart.yaml",
+		"deploy/kustomization.yaml",
+		"deploy/README.md",
+		"deploy/base/svc.yml",
+		"deploy/overlays/prod/patch.yaml",
+		"deploy/overlays/prod/skip.yaml",
+		"deploy/overlays/dev/patch.json",
+		"deploy/.git/config.yaml",
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deploy := filepath.Join(dir, "deploy")
+	rel := func(names ...string) []string {
+		for i, n := range names {
+			names[i] = filepath.Join(dir, n)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		opts k8s.FindOptions
+		want []string
+	}{
+		{
+			name: "directory",
+			args: []string{deploy},
+			want: rel("deploy/app.yaml"),
+		},
+		{
+			name: "recursive directory",
+			args: []string{deploy},
+			opts: k8s.FindOptions{Recursive: true},
+			want: rel("deploy/app.yaml", "deploy/base/svc.yml", "deploy/overlays/dev/patch.json",
+				"deploy/overlays/prod/patch.yaml", "deploy/overlays/prod/skip.yaml"),
+		},
+		{
+			name: "recursive with exclude",
+			args: []string{deploy},
+			opts: k8s.FindOptions{Recursive: true, Exclude: []string{"skip.yaml", "base"}},
+			want: rel("deploy/app.yaml", "deploy/overlays/dev/patch.json", "deploy/overlays/prod/patch.yaml"),
+		},
+		{
+			name: "all files",
+			args: []string{deploy},
+			opts: k8s.FindOptions{AllFiles: true},
+			want: rel("deploy/Chart.yaml", "deploy/app.yaml", "deploy/kustomization.yaml"),
+		},
+		{
+			name: "double star glob",
+			args: []string{filepath.Join(deploy, "overlays/**/*.yaml")},
+			want: rel("deploy/overlays/prod/patch.yaml", "deploy/overlays/prod/skip.yaml"),
+		},
+		{
+			name: "single star glob only enters matching directories",
+			args: []string{filepath.Join(deploy, "overlays/*/patch.*"), filepath.Join(deploy, "*/*.yml")},
+			want: rel("deploy/overlays/dev/patch.json", "deploy/overlays/prod/patch.yaml", "deploy/base/svc.yml"),
+		},
+		{
+			name: "explicit file is never skipped",
+			args: []string{filepath.Join(deploy, "Chart.yaml"), "-"},
+			want: append(rel("deploy/Chart.yaml"), "-"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := k8s.FindManifests(tt.args, tt.opts)
+			if err != nil {
+				t.Fatalf("FindManifests failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package k8s
+
+import "fmt"
+
+// gatewayGroup is the API group of the Gateway API
+const gatewayGroup = "gateway.networking.k8s.io"
+
+// analyzeGateway records the GatewayClass and certificate Secrets of a
+// Gateway
+func (g *Graph) analyzeGateway(r Resource, from Node) {
+	spec := r.root().get("spec")
+	if class := spec.get("gatewayClassName"); class.str() != "" {
+		to := Node{Group: gatewayGroup, Kind: "GatewayClass", Name: class.str()}
+		g.addEdge(Edge{From: from, To: to, Type: EdgeReferences, Field: class.path})
+	}
+	for _, listener := range spec.get("listeners").items() {
+		for _, ref := range listener.get("tls").get("certificateRefs").items() {
+			g.objectRef(from, ref, "", "Secret", EdgeReferences)
+		}
+	}
+}
+
+// analyzeRoute records the parent Gateways and backends of a route
+func (g *Graph) analyzeRoute(r Resource, from Node) {
+	spec := r.root().get("spec")
+	for _, ref := range spec.get("parentRefs").items() {
+		if e, ok := g.objectRef(from, ref, gatewayGroup, "Gateway", EdgeAttaches); ok && !e.Dangling {
+			g.checkAllowedRoutes(from, e.To, ref)
+		}
+	}
+	for _, rule := range spec.get("rules").items() {
+		for _, ref := range rule.get("backendRefs").items() {
+			g.objectRef(from, ref, "", "Service", EdgeRoutes)
+		}
+	}
+}
+
+// objectRef records an edge for a Gateway API object reference with
+// optional group, kind and namespace fields. References to another
+// namespace are checked against the loaded ReferenceGrants; parentRefs are
+// governed by the Gateway's allowedRoutes instead.
+func (g *Graph) objectRef(from Node, ref field, group, kind string, t EdgeType) (Edge, bool) {
+	name := ref.get("name")
+	if name.str() == "" {
+		return Edge{}, false
+	}
+	if v, ok := ref.get("group").value.(string); ok {
+		group = v
+	}
+	if v := ref.get("kind").str(); v != "" {
+		kind = v
+	}
+	namespace := from.Namespace
+	if v := ref.get("namespace").str(); v != "" {
+		namespace = v
+	}
+	to := Node{Group: group, Kind: kind, Namespace: namespace, Name: name.str()}
+	e := g.addEdge(Edge{From: from, To: to, Type: t, Field: name.path})
+
+	if t != EdgeAttaches && e.To.Namespace != "" && e.To.Namespace != from.Namespace {
+		if grant, ok := g.referenceGrant(from, e.To); ok {
+			g.addEdge(Edge{From: grant, To: from, Type: EdgePermits, Field: "spec"})
+		} else {
+			g.addIssue(Issue{Node: from, Field: ref.path, Severity: Error,
+				Message: fmt.Sprintf("no ReferenceGrant in namespace %s allows the reference to %s", e.To.Namespace, e.To)})
+		}
+	}
+	return e, true
+}
+
+// referenceGrant returns the ReferenceGrant in to's namespace that allows
+// from to refer to it
+func (g *Graph) referenceGrant(from, to Node) (Node, bool) {
+	for _, grant := range g.index.byKind["ReferenceGrant"] {
+		n := g.Node(*grant)
+		if n.Namespace != to.Namespace {
+			continue
+		}
+		spec := grant.root().get("spec")
+		fromOK, toOK := false, false
+		for _, f := range spec.get("from").items() {
+			if f.get("group").str() == from.Group && f.get("kind").str() == from.Kind && f.get("namespace").str() == from.Namespace {
+				fromOK = true
+			}
+		}
+		for _, t := range spec.get("to").items() {
+			if name := t.get("name").str(); t.get("group").str() == to.Group && t.get("kind").str() == to.Kind && (name == "" || name == to.Name) {
+				toOK = true
+			}
+		}
+		if fromOK && toOK {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
+
+// checkAllowedRoutes records an issue if no listener of a Gateway accepts
+// routes from the route's namespace. A parentRef sectionName limits the
+// check to that listener.
+func (g *Graph) checkAllowedRoutes(route, gateway Node, ref field) {
+	res, ok := g.index.byKey[gateway.key()]
+	if !ok {
+		return
+	}
+	section := ref.get("sectionName").str()
+	nsLabels := g.namespaceLabels()
+	for _, listener := range res.root().get("spec").get("listeners").items() {
+		if section != "" && listener.get("name").str() != section {
+			continue
+		}
+		namespaces := listener.get("allowedRoutes").get("namespaces")
+		switch namespaces.get("from").str() {
+		case "All":
+			return
+		case "Selector":
+			if sel, err := ParseLabelSelector(namespaces.get("selector").value); err == nil && sel.Matches(nsLabels(route.Namespace)) {
+				return
+			}
+		default: // Same
+			if route.Namespace == gateway.Namespace {
+				return
+			}
+		}
+	}
+	g.addIssue(Issue{Node: route, Field: ref.path, Severity: Warning,
+		Message: fmt.Sprintf("%s does not allow routes from namespace %s", gateway, route.Namespace)})
+}
@@ -0,0 +1,150 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestGatewayAPI(t *testing.T) {
+	content := `apiVersion: gateway.networking.k8s.io/v1
+kind: GatewayClass
+metadata:
+  name: envoy
+spec:
+  controllerName: example.com/gateway-controller
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: Gateway
+metadata:
+  name: public
+  namespace: infra
+spec:
+  gatewayClassName: envoy
+  listeners:
+    - name: https
+      protocol: HTTPS
+      port: 443
+      tls:
+        certificateRefs:
+          - name: wildcard-tls
+      allowedRoutes:
+        namespaces:
+          from: Selector
+          selector:
+            matchLabels:
+              gateway-access: "true"
+    - name: internal
+      protocol: HTTP
+      port: 8080
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: wildcard-tls
+  namespace: infra
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+  labels:
+    gateway-access: "true"
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: shop
+  namespace: shop
+spec:
+  parentRefs:
+    - name: public
+      namespace: infra
+      sectionName: https
+  rules:
+    - backendRefs:
+        - name: web
+          port: 80
+        - name: search
+          namespace: search
+          port: 80
+        - name: legacy
+          namespace: legacy
+          port: 80
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: GRPCRoute
+metadata:
+  name: internal
+  namespace: shop
+spec:
+  parentRefs:
+    - name: public
+      namespace: infra
+      sectionName: internal
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: search
+  namespace: search
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: legacy
+  namespace: legacy
+---
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: ReferenceGrant
+metadata:
+  name: shop-routes
+  namespace: search
+spec:
+  from:
+    - group: gateway.networking.k8s.io
+      kind: HTTPRoute
+      namespace: shop
+  to:
+    - group: ""
+      kind: Service
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+	nodes := g.Nodes()
+
+	tests := []struct {
+		node k8s.Node
+		want []string
+	}{
+		{nodes[1], []string{"→ Uses GatewayClass/envoy", "→ Uses Secret/wildcard-tls", "← Attached by HTTPRoute/shop", "← Attached by GRPCRoute/internal"}},
+		{nodes[4], []string{"→ Attaches to Gateway/public", "→ Routes to Service/web", "→ Routes to Service/search", "→ Routes to Service/legacy", "← Permitted by ReferenceGrant/shop-routes"}},
+	}
+	for _, tt := range tests {
+		if got := g.Relations(tt.node); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tt.node, strings.Join(tt.want, "\n"), strings.Join(got, "\n"))
+		}
+	}
+
+	var issues []string
+	for _, issue := range g.Issues() {
+		issues = append(issues, issue.String())
+	}
+	want := []string{
+		"HTTPRoute/shop: spec.rules[0].backendRefs[2]: no ReferenceGrant in namespace legacy allows the reference to Service/legacy",
+		"GRPCRoute/internal: spec.parentRefs[0]: Gateway/public does not allow routes from namespace shop",
+	}
+	if strings.Join(issues, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected issues\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(issues, "\n"))
+	}
+}
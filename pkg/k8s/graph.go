@@ -0,0 +1,574 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Node identifies a resource in a Graph
+type Node struct {
+	Group     string
+	Version   string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// NodeOf returns the node identifying a resource
+func NodeOf(r Resource) Node {
+	group, version := splitAPIVersion(r.APIVersion)
+	return Node{
+		Group:     group,
+		Version:   version,
+		Kind:      r.Kind,
+		Namespace: r.Metadata.Namespace,
+		Name:      r.Metadata.Name,
+	}
+}
+
+// GraphOptions controls how BuildGraphWithOptions resolves relationships
+type GraphOptions struct {
+	// DefaultNamespace is assumed for namespaced resources that do not set
+	// metadata.namespace, as kubectl does. Defaults to "default".
+	DefaultNamespace string
+}
+
+// clusterScoped lists the built-in kinds that do not live in a namespace
+var clusterScoped = map[string]bool{
+	"APIService":                       true,
+	"CSIDriver":                        true,
+	"CSINode":                          true,
+	"CertificateSigningRequest":        true,
+	"ClusterIssuer":                    true,
+	"ClusterRole":                      true,
+	"ClusterRoleBinding":               true,
+	"ComponentStatus":                  true,
+	"CustomResourceDefinition":         true,
+	"FlowSchema":                       true,
+	"GatewayClass":                     true,
+	"IngressClass":                     true,
+	"MutatingWebhookConfiguration":     true,
+	"Namespace":                        true,
+	"Node":                             true,
+	"PersistentVolume":                 true,
+	"PriorityClass":                    true,
+	"PriorityLevelConfiguration":       true,
+	"RuntimeClass":                     true,
+	"StorageClass":                     true,
+	"ValidatingAdmissionPolicy":        true,
+	"ValidatingAdmissionPolicyBinding": true,
+	"ValidatingWebhookConfiguration":   true,
+	"VolumeAttachment":                 true,
+}
+
+// IsClusterScoped reports whether resources of kind are cluster-scoped
+func IsClusterScoped(kind string) bool {
+	return clusterScoped[kind]
+}
+
+// implicitResources are created by Kubernetes in every namespace, so
+// references to them resolve even though they are never in manifests
+var implicitResources = map[string]bool{
+	"ConfigMap/kube-root-ca.crt": true,
+	"ServiceAccount/default":     true,
+}
+
+// implicitKinds are installed with the cluster or its controllers rather
+// than with applications, so references to them resolve whatever the name
+var implicitKinds = map[string]bool{
+	"GatewayClass": true,
+	"IngressClass": true,
+	"StorageClass": true,
+}
+
+// IsImplicit reports whether n is provided by the cluster rather than the
+// manifests: created by Kubernetes in every namespace, such as the default
+// ServiceAccount, or a class installed by the platform, such as a
+// StorageClass
+func IsImplicit(n Node) bool {
+	if implicitKinds[n.Kind] {
+		return true
+	}
+	return n.Group == "" && n.Namespace != "" && implicitResources[n.String()]
+}
+
+// normalize returns n with the namespace the API server would give it: none
+// for cluster-scoped kinds and the default namespace when it is unset
+func (o GraphOptions) normalize(n Node) Node {
+	switch {
+	case IsClusterScoped(n.Kind):
+		n.Namespace = ""
+	case n.Namespace == "":
+		n.Namespace = o.DefaultNamespace
+		if n.Namespace == "" {
+			n.Namespace = "default"
+		}
+	}
+	return n
+}
+
+// String formats the node as Kind/name
+func (n Node) String() string {
+	return n.Kind + "/" + n.Name
+}
+
+// key identifies a node by API group, kind, namespace and name. The version
+// is left out, so that a reference resolves to the loaded resource whichever
+// version it was written in.
+type key struct {
+	group, kind, namespace, name string
+}
+
+func (n Node) key() key {
+	return key{n.Group, n.Kind, n.Namespace, n.Name}
+}
+
+// ungroupedKey identifies a node by kind, namespace and name only, to
+// resolve references that do not name an API group
+func (n Node) ungroupedKey() key {
+	return key{kind: n.Kind, namespace: n.Namespace, name: n.Name}
+}
+
+// EdgeType describes how one resource relates to another
+type EdgeType string
+
+const (
+	// EdgeSelects is a label selector matching a workload or pod
+	EdgeSelects EdgeType = "selects"
+	// EdgeMounts is a volume backed by a ConfigMap or Secret
+	EdgeMounts EdgeType = "mounts"
+	// EdgeReferences is any other reference by name, e.g. an env var source
+	EdgeReferences EdgeType = "references"
+	// EdgeRoutes is traffic routed to a backend
+	EdgeRoutes EdgeType = "routes"
+	// EdgeScales is an autoscaler's scale target
+	EdgeScales EdgeType = "scales"
+	// EdgeBinds is a PersistentVolumeClaim bound to a PersistentVolume, or
+	// an RBAC binding's role and ServiceAccount subjects
+	EdgeBinds EdgeType = "binds"
+	// EdgeAggregates is an aggregated ClusterRole including another's rules
+	EdgeAggregates EdgeType = "aggregates"
+	// EdgeAttaches is a Gateway API route attached to a parent Gateway
+	EdgeAttaches EdgeType = "attaches"
+	// EdgePermits is a ReferenceGrant allowing a cross-namespace reference
+	EdgePermits EdgeType = "permits"
+	// EdgeOwnedBy is an object owned by another through ownerReferences,
+	// e.g. a ReplicaSet created by a Deployment
+	EdgeOwnedBy EdgeType = "owned-by"
+)
+
+// edgeVerbs holds the active and passive verbs describing each edge type
+var edgeVerbs = map[EdgeType][2]string{
+	EdgeSelects:    {"Selects", "Selected by"},
+	EdgeMounts:     {"Mounts", "Mounted by"},
+	EdgeReferences: {"Uses", "Used by"},
+	EdgeRoutes:     {"Routes to", "Routed from"},
+	EdgeScales:     {"Scales", "Scaled by"},
+	EdgeBinds:      {"Binds", "Bound by"},
+	EdgeAggregates: {"Aggregates", "Aggregated by"},
+	EdgeAttaches:   {"Attaches to", "Attached by"},
+	EdgePermits:    {"Permits", "Permitted by"},
+	EdgeOwnedBy:    {"Owned by", "Owns"},
+}
+
+// Verb describes the edge from the source's point of view, e.g. "Selects"
+func (t EdgeType) Verb() string {
+	if v, ok := edgeVerbs[t]; ok {
+		return v[0]
+	}
+	return string(t)
+}
+
+// PassiveVerb describes the edge from the target's point of view,
+// e.g. "Selected by"
+func (t EdgeType) PassiveVerb() string {
+	if v, ok := edgeVerbs[t]; ok {
+		return v[1]
+	}
+	return string(t) + " by"
+}
+
+// Edge is a directed relationship between two resources
+type Edge struct {
+	From  Node
+	To    Node
+	Type  EdgeType
+	Field string // Path of the field in From that created the edge
+
+	// Container and Key are set for pod spec references made by a single
+	// container or to a single ConfigMap or Secret key
+	Container string
+	Key       string
+
+	// Dangling is set when To is not among the loaded resources, e.g. a
+	// ConfigMap that is expected to exist in the cluster already. Edges to
+	// implicit resources such as the default ServiceAccount or a
+	// StorageClass never dangle.
+	Dangling bool
+}
+
+// Severity ranks how serious an Issue is
+type Severity int
+
+const (
+	// Warning is a likely problem, such as a reference that cannot be checked
+	Warning Severity = iota
+	// Error is a definite problem the API server or kubelet would reject
+	Error
+)
+
+// String returns "warning" or "error"
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue is a problem found while analyzing the relationships of a resource
+type Issue struct {
+	Node     Node
+	Field    string // Path of the offending field
+	Severity Severity
+	Message  string
+}
+
+// String formats the issue as "Kind/name: field: message"
+func (i Issue) String() string {
+	if i.Field == "" {
+		return fmt.Sprintf("%s: %s", i.Node, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Node, i.Field, i.Message)
+}
+
+// Graph holds the relationships between a set of resources
+type Graph struct {
+	opts   GraphOptions
+	nodes  []Node
+	index  *index
+	edges  []Edge
+	out    map[key][]Edge
+	in     map[key][]Edge
+	issues []Issue
+}
+
+// BuildGraph computes the relationships between resources with the default
+// options. Lookups go through indexes built once up front, so the cost grows
+// linearly with the number of resources and edges rather than quadratically.
+func BuildGraph(resources []Resource) *Graph {
+	return BuildGraphWithOptions(resources, GraphOptions{})
+}
+
+// BuildGraphWithOptions computes the relationships between resources.
+// References and selectors only match resources in the same namespace, and
+// the nodes of the graph carry the namespace each resource is applied to.
+func BuildGraphWithOptions(resources []Resource, opts GraphOptions) *Graph {
+	ix := newIndex(resources, opts)
+	g := &Graph{
+		opts:  opts,
+		nodes: ix.nodes,
+		index: ix,
+		out:   make(map[key][]Edge),
+		in:    make(map[key][]Edge),
+	}
+	for i := range resources {
+		g.analyze(resources[i])
+	}
+	return g
+}
+
+// addEdge records an edge, resolving its target to a loaded resource, and
+// returns the recorded edge
+func (g *Graph) addEdge(e Edge) Edge {
+	e.To = g.opts.normalize(e.To)
+	if n, ok := g.index.resolve(e.To); ok {
+		e.To = n
+	} else if !IsImplicit(e.To) {
+		e.Dangling = true
+	}
+	g.edges = append(g.edges, e)
+	g.out[e.From.key()] = append(g.out[e.From.key()], e)
+	g.in[e.To.key()] = append(g.in[e.To.key()], e)
+	return e
+}
+
+// addIssue records an issue
+func (g *Graph) addIssue(i Issue) {
+	g.issues = append(g.issues, i)
+}
+
+// Issues returns every issue found while building the graph
+func (g *Graph) Issues() []Issue {
+	return g.issues
+}
+
+// IssuesFor returns the issues found for n
+func (g *Graph) IssuesFor(n Node) []Issue {
+	k := g.lookup(n).key()
+	var issues []Issue
+	for _, i := range g.issues {
+		if i.Node.key() == k {
+			issues = append(issues, i)
+		}
+	}
+	return issues
+}
+
+// Nodes returns the nodes of all loaded resources in load order
+func (g *Graph) Nodes() []Node {
+	return g.nodes
+}
+
+// Edges returns every edge in the graph
+func (g *Graph) Edges() []Edge {
+	return g.edges
+}
+
+// Node returns the node of a resource, with its namespace defaulted
+func (g *Graph) Node(r Resource) Node {
+	return g.opts.normalize(NodeOf(r))
+}
+
+// Dangling returns the edges whose target is not among the loaded resources
+func (g *Graph) Dangling() []Edge {
+	var dangling []Edge
+	for _, e := range g.edges {
+		if e.Dangling {
+			dangling = append(dangling, e)
+		}
+	}
+	return dangling
+}
+
+// Out returns the edges starting at n
+func (g *Graph) Out(n Node) []Edge {
+	return g.out[g.lookup(n).key()]
+}
+
+// In returns the edges ending at n
+func (g *Graph) In(n Node) []Edge {
+	return g.in[g.lookup(n).key()]
+}
+
+// Resource returns the loaded resource for a node
+func (g *Graph) Resource(n Node) (*Resource, bool) {
+	res, ok := g.index.byKey[g.lookup(n).key()]
+	return res, ok
+}
+
+// lookup normalizes n and resolves it to a loaded resource, so that nodes
+// without an API group find the resource they name
+func (g *Graph) lookup(n Node) Node {
+	n = g.opts.normalize(n)
+	if resolved, ok := g.index.resolve(n); ok {
+		return resolved
+	}
+	return n
+}
+
+// Relations describes the edges of n as text, outgoing edges first, e.g.
+// "→ Selects Deployment/web" and "← Selected by Service/web". Dangling
+// edges are suffixed with "(missing)".
+func (g *Graph) Relations(n Node) []string {
+	var relations []string
+	seen := make(map[string]bool)
+	add := func(rel string) {
+		if !seen[rel] {
+			seen[rel] = true
+			relations = append(relations, rel)
+		}
+	}
+	for _, e := range g.Out(n) {
+		rel := fmt.Sprintf("→ %s %s", e.Type.Verb(), e.To)
+		if e.Dangling {
+			rel += " (missing)"
+		}
+		add(rel)
+	}
+	for _, e := range g.In(n) {
+		add(fmt.Sprintf("← %s %s", e.Type.PassiveVerb(), e.From))
+	}
+	return relations
+}
+
+// analyze records the outgoing edges of a resource and any issues found
+func (g *Graph) analyze(r Resource) {
+	from := g.Node(r)
+	root := r.root()
+
+	// A volume mounted by several containers has a ref per container, but
+	// its keys are checked once
+	checked := make(map[[2]string]bool)
+	for _, ref := range PodSpecRefs(r) {
+		to := Node{Kind: ref.Kind, Namespace: from.Namespace, Name: ref.Name}
+		e := g.addEdge(Edge{From: from, To: to, Type: ref.Type, Field: ref.Field, Container: ref.Container, Key: ref.Key})
+		if ref.Key != "" && !ref.Optional && !e.Dangling && !checked[[2]string{ref.Field, ref.Key}] {
+			checked[[2]string{ref.Field, ref.Key}] = true
+			g.checkKey(from, e)
+		}
+	}
+	g.analyzeRBAC(r, from)
+	g.analyzeOwners(r, from)
+	if r.Kind == "StatefulSet" {
+		// Each replica gets a claim from every template
+		for _, tmpl := range root.get("spec").get("volumeClaimTemplates").items() {
+			g.storageClass(from, tmpl.get("spec"))
+		}
+	}
+
+	switch r.Kind {
+	case "Service":
+		selector := root.get("spec").get("selector")
+		if labels := selector.stringMap(); len(labels) > 0 {
+			g.selects(from, selector, SelectorFromMap(labels), false)
+			g.checkTargetPorts(from)
+		}
+
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		// A Job's selector is usually generated by the API server
+		selector := root.get("spec").get("selector")
+		if sel := g.labelSelector(from, selector); sel != nil {
+			g.selectsOwnPods(r, from, selector, sel)
+		}
+
+	case "ReplicationController":
+		// The selector is a plain map that defaults to the template labels
+		selector := root.get("spec").get("selector")
+		if labels := selector.stringMap(); len(labels) > 0 {
+			g.selectsOwnPods(r, from, selector, SelectorFromMap(labels))
+		}
+
+	case "NetworkPolicy":
+		selector := root.get("spec").get("podSelector")
+		if sel := g.labelSelector(from, selector); sel != nil {
+			g.selects(from, selector, sel, false)
+		}
+
+	case "PodDisruptionBudget":
+		selector := root.get("spec").get("selector")
+		if sel := g.labelSelector(from, selector); sel != nil {
+			g.selects(from, selector, sel, false)
+		}
+
+	case "Ingress":
+		g.analyzeIngress(r, from)
+
+	case "Gateway":
+		g.analyzeGateway(r, from)
+
+	case "HTTPRoute", "GRPCRoute", "TCPRoute", "TLSRoute", "UDPRoute":
+		g.analyzeRoute(r, from)
+
+	case "HorizontalPodAutoscaler":
+		target := root.get("spec").get("scaleTargetRef")
+		if kind, name := target.get("kind").str(), target.get("name").str(); kind != "" && name != "" {
+			group, _ := splitAPIVersion(target.get("apiVersion").str())
+			to := Node{Group: group, Kind: kind, Namespace: from.Namespace, Name: name}
+			g.addEdge(Edge{From: from, To: to, Type: EdgeScales, Field: target.path})
+		}
+
+	case "PersistentVolumeClaim":
+		spec := root.get("spec")
+		g.storageClass(from, spec)
+		if name := spec.get("volumeName"); name.str() != "" {
+			to := Node{Kind: "PersistentVolume", Name: name.str()}
+			g.addEdge(Edge{From: from, To: to, Type: EdgeBinds, Field: name.path})
+		}
+
+	case "PersistentVolume":
+		spec := root.get("spec")
+		g.storageClass(from, spec)
+		claim := spec.get("claimRef")
+		if name := claim.get("name").str(); name != "" {
+			to := Node{Kind: "PersistentVolumeClaim", Namespace: claim.get("namespace").str(), Name: name}
+			g.addEdge(Edge{From: from, To: to, Type: EdgeBinds, Field: claim.path})
+		}
+	}
+}
+
+// storageClass records the StorageClass requested by a PersistentVolume or
+// PersistentVolumeClaim spec. A claim that does not set storageClassName
+// gets the loaded default StorageClass, if any; an empty name means none.
+func (g *Graph) storageClass(from Node, spec field) {
+	name := spec.get("storageClassName")
+	class, set := name.value.(string)
+	if !set && from.Kind != "PersistentVolume" {
+		class = g.index.defaultStorageClass
+	}
+	if class == "" {
+		return
+	}
+	to := Node{Kind: "StorageClass", Name: class}
+	g.addEdge(Edge{From: from, To: to, Type: EdgeReferences, Field: name.path})
+}
+
+// checkKey records a warning if the ConfigMap or Secret an edge points to
+// does not hold the edge's key
+func (g *Graph) checkKey(from Node, e Edge) {
+	res, ok := g.index.byKey[e.To.key()]
+	if !ok || (res.Kind != "ConfigMap" && res.Kind != "Secret") {
+		return
+	}
+	if !dataKeys(*res)[e.Key] {
+		g.addIssue(Issue{Node: from, Field: e.Field, Severity: Warning,
+			Message: fmt.Sprintf("key %q not found in %s", e.Key, e.To)})
+	}
+}
+
+// selects records the workloads and pods in from's namespace that sel
+// matches, or only the Pods if podsOnly is set
+func (g *Graph) selects(from Node, selector field, sel *LabelSelector, podsOnly bool) {
+	for _, res := range g.index.selectPods(from.Namespace, sel) {
+		if podsOnly && res.Kind != "Pod" {
+			continue
+		}
+		g.addEdge(Edge{From: from, To: g.Node(*res), Type: EdgeSelects, Field: selector.path})
+	}
+}
+
+// selectsOwnPods records the Pods a workload's selector matches, and an issue
+// if the selector does not match the workload's own pod template
+func (g *Graph) selectsOwnPods(r Resource, from Node, selector field, sel *LabelSelector) {
+	g.selects(from, selector, sel, true)
+	if labels := podTemplateLabels(r); !sel.Matches(labels) {
+		g.addIssue(Issue{Node: from, Field: selector.path, Severity: Error,
+			Message: fmt.Sprintf("selector %s does not match the pod template labels", sel)})
+	}
+}
+
+// labelSelector parses the LabelSelector at f, recording an issue if it is
+// invalid. It returns nil if there is no valid selector.
+func (g *Graph) labelSelector(from Node, f field) *LabelSelector {
+	sel, err := ParseLabelSelector(f.value)
+	if err != nil {
+		g.addIssue(Issue{Node: from, Field: f.path, Severity: Error, Message: err.Error()})
+		return nil
+	}
+	return sel
+}
+
+// SortEdges orders edges by source, type and target for stable output
+func SortEdges(edges []Edge) {
+	sort.SliceStable(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.From.Namespace != b.From.Namespace {
+			return a.From.Namespace < b.From.Namespace
+		}
+		if a.From != b.From {
+			return a.From.String() < b.From.String()
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.To.String() < b.To.String()
+	})
+}
+
+// splitAPIVersion splits an apiVersion such as apps/v1 into group and version
+func splitAPIVersion(apiVersion string) (string, string) {
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		return apiVersion[:i], apiVersion[i+1:]
+	}
+	return "", apiVersion
+}
@@ -0,0 +1,557 @@
+package k8s_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestBuildGraph(t *testing.T) {
+	resources, err := k8s.ParseFromFile("../../examples/multi-resource.yaml")
+	if err != nil {
+		t.Fatalf("ParseFromFile failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+
+	find := func(kind, name string) k8s.Node {
+		for _, n := range g.Nodes() {
+			if n.Kind == kind && n.Name == name {
+				return n
+			}
+		}
+		t.Fatalf("Node %s/%s not found", kind, name)
+		return k8s.Node{}
+	}
+	hasEdge := func(edges []k8s.Edge, want k8s.Edge) bool {
+		for _, e := range edges {
+			if e == want {
+				return true
+			}
+		}
+		return false
+	}
+
+	svc := find("Service", "web-app-svc")
+	deploy := find("Deployment", "web-app")
+	if deploy.Group != "apps" || deploy.Version != "v1" || deploy.Namespace != "web-app" {
+		t.Errorf("Unexpected node %+v", deploy)
+	}
+
+	tests := []struct {
+		name  string
+		edges []k8s.Edge
+		want  k8s.Edge
+	}{
+		{
+			name:  "service selects deployment",
+			edges: g.Out(svc),
+			want:  k8s.Edge{From: svc, To: deploy, Type: k8s.EdgeSelects, Field: "spec.selector"},
+		},
+		{
+			name:  "deployment selected by service",
+			edges: g.In(deploy),
+			want:  k8s.Edge{From: svc, To: deploy, Type: k8s.EdgeSelects, Field: "spec.selector"},
+		},
+		{
+			name:  "deployment mounts configmap",
+			edges: g.Out(deploy),
+			want: k8s.Edge{From: deploy, To: find("ConfigMap", "web-config"), Type: k8s.EdgeMounts,
+				Field: "spec.template.spec.volumes[0].configMap.name", Container: "web-app"},
+		},
+		{
+			name:  "deployment mounts secret",
+			edges: g.Out(deploy),
+			want: k8s.Edge{From: deploy, To: find("Secret", "app-secrets"), Type: k8s.EdgeMounts,
+				Field: "spec.template.spec.volumes[1].secret.secretName", Container: "web-app"},
+		},
+		{
+			name:  "deployment references secret",
+			edges: g.Out(deploy),
+			want: k8s.Edge{From: deploy, To: find("Secret", "app-secrets"), Type: k8s.EdgeReferences,
+				Field: "spec.template.spec.containers[0].env[0].valueFrom.secretKeyRef.name", Container: "web-app", Key: "db-password"},
+		},
+		{
+			name:  "ingress routes to service",
+			edges: g.In(svc),
+			want: k8s.Edge{From: find("Ingress", "web-app-ingress"), To: svc, Type: k8s.EdgeRoutes,
+				Field: "spec.rules[0].http.paths[0].backend.service.name"},
+		},
+		{
+			name:  "hpa scales deployment",
+			edges: g.In(deploy),
+			want: k8s.Edge{From: find("HorizontalPodAutoscaler", "web-app-hpa"), To: deploy, Type: k8s.EdgeScales,
+				Field: "spec.scaleTargetRef"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !hasEdge(tt.edges, tt.want) {
+				t.Errorf("Expected edge %+v, got %+v", tt.want, tt.edges)
+			}
+		})
+	}
+}
+
+func TestBuildGraphNamespaces(t *testing.T) {
+	content := `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: tenant-a
+spec:
+  selector:
+    app: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: tenant-a
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      volumes:
+        - name: config
+          configMap:
+            name: web-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: tenant-b
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      volumes:
+        - name: config
+          configMap:
+            name: web-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: tenant-b
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: tenant-a
+  namespace: ignored
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	g := k8s.BuildGraph(resources)
+	nodes := g.Nodes()
+	if len(nodes) != len(resources) {
+		t.Fatalf("Expected %d nodes, got %d", len(resources), len(nodes))
+	}
+	wantNamespaces := []string{"tenant-a", "tenant-a", "tenant-b", "tenant-b", "default", "default", "default", ""}
+	for i, n := range nodes {
+		if n.Namespace != wantNamespaces[i] {
+			t.Errorf("Expected %s in namespace %q, got %q", n, wantNamespaces[i], n.Namespace)
+		}
+	}
+
+	tests := []struct {
+		name string
+		node k8s.Node
+		want []string
+	}{
+		{"service only selects its namespace", nodes[0], []string{"tenant-a"}},
+		{"reference resolves in tenant-b", nodes[2], []string{"tenant-b"}},
+		{"unset namespace matches default", nodes[5], []string{"default"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range g.Out(tt.node) {
+				got = append(got, e.To.Namespace)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected targets in %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// A reference to a ConfigMap missing from its namespace does not resolve
+	// to the one in another namespace
+	out := g.Out(nodes[1])
+	if len(out) != 1 || out[0].To.Namespace != "tenant-a" {
+		t.Fatalf("Expected one reference in tenant-a, got %+v", out)
+	}
+	if _, ok := g.Resource(out[0].To); ok {
+		t.Error("Expected reference to ConfigMap/web-config in tenant-a not to resolve")
+	}
+
+	// Lookups by the parsed resource apply the same defaulting
+	if got := len(g.Out(k8s.NodeOf(resources[5]))); got != 1 {
+		t.Errorf("Expected 1 edge for Service/web in the default namespace, got %d", got)
+	}
+
+	// With another default namespace the unqualified ConfigMap moves to it
+	g = k8s.BuildGraphWithOptions(resources, k8s.GraphOptions{DefaultNamespace: "tenant-a"})
+	if n := g.Node(resources[4]); n.Namespace != "tenant-a" {
+		t.Errorf("Expected ConfigMap/web-config in tenant-a, got %q", n.Namespace)
+	}
+	if _, ok := g.Resource(g.Out(nodes[1])[0].To); !ok {
+		t.Error("Expected reference to ConfigMap/web-config in tenant-a to resolve")
+	}
+}
+
+// generateResources creates n resources shaped like a typical application
+// bundle: each app has a Deployment, Service, ConfigMap and Secret
+func generateResources(n int) []k8s.Resource {
+	resources := make([]k8s.Resource, 0, n)
+	for i := 0; len(resources) < n; i++ {
+		app := fmt.Sprintf("app-%d", i)
+		ns := fmt.Sprintf("team-%d", i%20)
+		labels := map[string]interface{}{"app": app, "tier": "backend"}
+		resources = append(resources,
+			k8s.Resource{
+				APIVersion: "apps/v1", Kind: "Deployment",
+				Metadata: k8s.Metadata{Name: app, Namespace: ns},
+				Spec: map[string]interface{}{
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{"labels": labels},
+						"spec": map[string]interface{}{
+							"volumes": []interface{}{
+								map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": app}},
+								map[string]interface{}{"name": "creds", "secret": map[string]interface{}{"secretName": app}},
+							},
+						},
+					},
+				},
+			},
+			k8s.Resource{
+				APIVersion: "v1", Kind: "Service",
+				Metadata: k8s.Metadata{Name: app, Namespace: ns},
+				Spec:     map[string]interface{}{"selector": labels},
+			},
+			k8s.Resource{APIVersion: "v1", Kind: "ConfigMap", Metadata: k8s.Metadata{Name: app, Namespace: ns}},
+			k8s.Resource{APIVersion: "v1", Kind: "Secret", Metadata: k8s.Metadata{Name: app, Namespace: ns}},
+		)
+	}
+	return resources[:n]
+}
+
+func TestBuildGraphGroups(t *testing.T) {
+	content := `apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - image: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  labels:
+    app: web
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  defaultBackend:
+    service:
+      name: web
+      port:
+        number: 80
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: web
+spec:
+  scaleTargetRef:
+    apiVersion: serving.knative.dev/v1
+    kind: Service
+    name: web
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+	nodes := g.Nodes()
+	if len(nodes) != 5 {
+		t.Fatalf("Expected both Services to be kept, got %v", nodes)
+	}
+	if got := len(g.Out(nodes[1])); got != 1 {
+		t.Errorf("Expected the core Service to select the Pod, got %d edges", got)
+	}
+
+	tests := []struct {
+		from  k8s.Node
+		group string
+	}{
+		{nodes[3], ""},                    // An Ingress backend names no group
+		{nodes[4], "serving.knative.dev"}, // The scale target names one
+	}
+	for _, tt := range tests {
+		edges := g.Out(tt.from)
+		if len(edges) != 1 || edges[0].Dangling || edges[0].To.Group != tt.group {
+			t.Errorf("%s: expected an edge to the Service in group %q, got %+v", tt.from, tt.group, edges)
+		}
+	}
+}
+
+func TestBuildGraphGenerated(t *testing.T) {
+	g := k8s.BuildGraph(generateResources(400))
+	// Each of the 100 apps has one selects and two mounts edges
+	if got := len(g.Edges()); got != 300 {
+		t.Errorf("Expected 300 edges, got %d", got)
+	}
+}
+
+func BenchmarkBuildGraph(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		resources := generateResources(n)
+		b.Run(fmt.Sprintf("resources=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				k8s.BuildGraph(resources)
+			}
+		})
+	}
+}
+
+func TestBuildGraphWorkloads(t *testing.T) {
+	content := `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  selector:
+    matchLabels:
+      app: agent
+  template:
+    metadata:
+      labels:
+        app: agent
+    spec:
+      volumes:
+        - name: config
+          configMap:
+            name: agent-config
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        metadata:
+          labels:
+            app: backup
+        spec:
+          containers:
+            - name: backup
+              env:
+                - name: PASSWORD
+                  valueFrom:
+                    secretKeyRef:
+                      name: backup-credentials
+                      key: password
+---
+apiVersion: v1
+kind: ReplicationController
+metadata:
+  name: legacy
+spec:
+  selector:
+    app: other
+  template:
+    metadata:
+      labels:
+        app: legacy
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: agent
+spec:
+  selector:
+    app: agent
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: backup
+spec:
+  selector:
+    app: backup
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+	nodes := g.Nodes()
+
+	tests := []struct {
+		node k8s.Node
+		want []string
+	}{
+		{nodes[0], []string{"→ Mounts ConfigMap/agent-config (missing)", "← Selected by Service/agent"}},
+		{nodes[1], []string{"→ Uses Secret/backup-credentials (missing)", "← Selected by Service/backup"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.node.String(), func(t *testing.T) {
+			if got := g.Relations(tt.node); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	out := g.Out(nodes[1])
+	if len(out) == 0 || out[0].Field != "spec.jobTemplate.spec.template.spec.containers[0].env[0].valueFrom.secretKeyRef.name" {
+		t.Errorf("Expected reference from the CronJob's job template, got %+v", out)
+	}
+
+	issues := g.IssuesFor(nodes[2])
+	if len(issues) != 1 || issues[0].Field != "spec.selector" {
+		t.Errorf("Expected selector mismatch on ReplicationController/legacy, got %v", issues)
+	}
+}
+
+func TestBuildGraphStorage(t *testing.T) {
+	content := `apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  name: standard
+  annotations:
+    storageclass.kubernetes.io/is-default-class: "true"
+provisioner: kubernetes.io/no-provisioner
+---
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  name: fast
+provisioner: kubernetes.io/no-provisioner
+---
+apiVersion: v1
+kind: PersistentVolume
+metadata:
+  name: data-pv
+spec:
+  storageClassName: fast
+  claimRef:
+    namespace: shop
+    name: data
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+  namespace: shop
+spec:
+  storageClassName: fast
+  volumeName: data-pv
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: scratch
+  namespace: shop
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+  namespace: shop
+spec:
+  template:
+    spec:
+      volumes:
+        - name: data
+          persistentVolumeClaim:
+            claimName: data
+  volumeClaimTemplates:
+    - metadata:
+        name: wal
+      spec:
+        storageClassName: fast
+    - metadata:
+        name: logs
+      spec:
+        storageClassName: ""
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+	nodes := g.Nodes()
+
+	tests := []struct {
+		node k8s.Node
+		want []string
+	}{
+		{nodes[2], []string{"→ Uses StorageClass/fast", "→ Binds PersistentVolumeClaim/data", "← Bound by PersistentVolumeClaim/data"}},
+		{nodes[4], []string{"→ Uses StorageClass/standard"}},
+		{nodes[5], []string{"→ Mounts PersistentVolumeClaim/data", "→ Uses StorageClass/fast"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.node.String(), func(t *testing.T) {
+			if got := g.Relations(tt.node); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+	if len(g.Dangling()) != 0 {
+		t.Errorf("Expected all storage references to resolve, got %+v", g.Dangling())
+	}
+}
@@ -0,0 +1,120 @@
+package k8s
+
+// index provides the lookups needed to compute relationships without
+// scanning every resource for every reference
+type index struct {
+	nodes []Node
+	byKey map[key]*Resource
+	keys  map[key]Node // Normalized node of each loaded resource
+
+	// byName holds the nodes of each ungrouped key, in load order
+	byName map[key][]Node
+
+	// byKind holds the loaded resources of the kinds some analyses scan
+	// as a whole, such as bindings and ClusterRoles
+	byKind map[string][]*Resource
+
+	// pods maps "namespace/key=value" pod template labels to the workloads
+	// and pods carrying them, and podLabels holds each of those resources'
+	// labels
+	pods      map[string][]*Resource
+	podLabels map[*Resource]map[string]string
+	podList   map[string][]*Resource // Workloads and pods per namespace, in load order
+
+	// defaultStorageClass is the StorageClass annotated as the default
+	defaultStorageClass string
+}
+
+// defaultStorageClassAnnotation marks the StorageClass used by claims that do
+// not request one
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+func newIndex(resources []Resource, opts GraphOptions) *index {
+	ix := &index{
+		byKey:     make(map[key]*Resource, len(resources)),
+		keys:      make(map[key]Node, len(resources)),
+		byName:    make(map[key][]Node),
+		byKind:    make(map[string][]*Resource),
+		pods:      make(map[string][]*Resource),
+		podLabels: make(map[*Resource]map[string]string),
+		podList:   make(map[string][]*Resource),
+	}
+	for i := range resources {
+		res := &resources[i]
+		n := opts.normalize(NodeOf(*res))
+		if _, dup := ix.byKey[n.key()]; !dup {
+			ix.nodes = append(ix.nodes, n)
+			ix.byName[n.ungroupedKey()] = append(ix.byName[n.ungroupedKey()], n)
+		}
+		ix.byKey[n.key()] = res
+		ix.keys[n.key()] = n
+
+		switch res.Kind {
+		case "RoleBinding", "ClusterRoleBinding", "ClusterRole", "ReferenceGrant":
+			ix.byKind[res.Kind] = append(ix.byKind[res.Kind], res)
+		}
+		if res.Kind == "StorageClass" && res.Metadata.Annotations[defaultStorageClassAnnotation] == "true" {
+			ix.defaultStorageClass = res.Metadata.Name
+		}
+
+		labels := podTemplateLabels(*res)
+		if labels == nil {
+			continue
+		}
+		ix.podLabels[res] = labels
+		ix.podList[n.Namespace] = append(ix.podList[n.Namespace], res)
+		for k, v := range labels {
+			posting := n.Namespace + "/" + k + "=" + v
+			ix.pods[posting] = append(ix.pods[posting], res)
+		}
+	}
+	return ix
+}
+
+// resolve returns the node of the loaded resource a normalized reference
+// points to. References only resolve within their own namespace. A
+// reference naming an API group only matches that group; one without
+// matches the core group first, then the first loaded resource of any group.
+func (ix *index) resolve(n Node) (Node, bool) {
+	if n.Group != "" {
+		resolved, ok := ix.keys[n.key()]
+		return resolved, ok
+	}
+	candidates := ix.byName[n.ungroupedKey()]
+	for _, c := range candidates {
+		if c.Group == "" {
+			return c, true
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0], true
+	}
+	return Node{}, false
+}
+
+// selectPods returns the workloads and pods in namespace whose labels match
+// selector. Only the candidates for the selector's rarest exact label are
+// checked when it has one.
+func (ix *index) selectPods(namespace string, selector *LabelSelector) []*Resource {
+	if selector == nil {
+		return nil
+	}
+	var candidates []*Resource
+	indexed := false
+	for k, v := range selector.MatchLabels {
+		posting := ix.pods[namespace+"/"+k+"="+v]
+		if !indexed || len(posting) < len(candidates) {
+			candidates, indexed = posting, true
+		}
+	}
+	if !indexed {
+		candidates = ix.podList[namespace]
+	}
+	var matches []*Resource
+	for _, res := range candidates {
+		if selector.Matches(ix.podLabels[res]) {
+			matches = append(matches, res)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,103 @@
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ingressClassAnnotation is the legacy way of choosing an IngressClass
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+// analyzeIngress records the backends, TLS secrets and IngressClass of an
+// Ingress in both the networking.k8s.io/v1 and the legacy
+// extensions/v1beta1 shape, and checks that backend service ports exist
+func (g *Graph) analyzeIngress(r Resource, from Node) {
+	spec := r.root().get("spec")
+
+	if class := spec.get("ingressClassName"); class.str() != "" {
+		to := Node{Kind: "IngressClass", Name: class.str()}
+		g.addEdge(Edge{From: from, To: to, Type: EdgeReferences, Field: class.path})
+	} else if class := r.Metadata.Annotations[ingressClassAnnotation]; class != "" {
+		// The annotation names a controller class rather than an object,
+		// so the edge resolves like any IngressClass whether or not one
+		// of that name is loaded
+		to := Node{Kind: "IngressClass", Name: class}
+		g.addEdge(Edge{From: from, To: to, Type: EdgeReferences, Field: "metadata.annotations." + ingressClassAnnotation})
+	}
+
+	for _, tls := range spec.get("tls").items() {
+		if name := tls.get("secretName"); name.str() != "" {
+			to := Node{Kind: "Secret", Namespace: from.Namespace, Name: name.str()}
+			g.addEdge(Edge{From: from, To: to, Type: EdgeReferences, Field: name.path})
+		}
+	}
+
+	g.ingressBackend(from, spec.get("defaultBackend"))
+	g.ingressBackend(from, spec.get("backend"))
+	for _, rule := range spec.get("rules").items() {
+		for _, p := range rule.get("http").get("paths").items() {
+			g.ingressBackend(from, p.get("backend"))
+		}
+	}
+}
+
+// ingressBackend records the Service or resource an Ingress backend routes to
+func (g *Graph) ingressBackend(from Node, backend field) {
+	if backend.value == nil {
+		return
+	}
+
+	// networking.k8s.io/v1: service.name and service.port.number or .name
+	service := backend.get("service")
+	name, port := service.get("name"), service.get("port").get("number")
+	if portName := service.get("port").get("name"); portName.str() != "" {
+		port = portName
+	}
+	// extensions/v1beta1: serviceName and servicePort
+	if name.str() == "" {
+		name, port = backend.get("serviceName"), backend.get("servicePort")
+	}
+	if name.str() != "" {
+		to := Node{Kind: "Service", Namespace: from.Namespace, Name: name.str()}
+		e := g.addEdge(Edge{From: from, To: to, Type: EdgeRoutes, Field: name.path})
+		if !e.Dangling && port.value != nil {
+			g.checkServicePort(from, e.To, port)
+		}
+		return
+	}
+
+	res := backend.get("resource")
+	if kind, name := res.get("kind").str(), res.get("name"); kind != "" && name.str() != "" {
+		group, _ := res.get("apiGroup").value.(string)
+		to := Node{Group: group, Kind: kind, Namespace: from.Namespace, Name: name.str()}
+		g.addEdge(Edge{From: from, To: to, Type: EdgeRoutes, Field: name.path})
+	}
+}
+
+// checkServicePort records an issue if a loaded Service has no port with
+// the given number or name
+func (g *Graph) checkServicePort(from, service Node, port field) {
+	res, ok := g.index.byKey[service.key()]
+	if !ok {
+		return
+	}
+	want := fmt.Sprint(port.value)
+	for _, p := range res.root().get("spec").get("ports").items() {
+		if number, ok := p.get("port").value.(int); ok && strconv.Itoa(number) == want {
+			return
+		}
+		if p.get("name").str() == want {
+			return
+		}
+	}
+	g.addIssue(Issue{Node: from, Field: port.path, Severity: Warning,
+		Message: fmt.Sprintf("%s has no port %s", service, servicePortString(port.value))})
+}
+
+// servicePortString formats a port number as is and a port name quoted
+func servicePortString(port interface{}) string {
+	if s, ok := port.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(port)
+}
@@ -0,0 +1,119 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestIngress(t *testing.T) {
+	content := `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - name: http
+      port: 80
+---
+apiVersion: networking.k8s.io/v1
+kind: IngressClass
+metadata:
+  name: nginx
+spec:
+  controller: k8s.io/ingress-nginx
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  ingressClassName: nginx
+  tls:
+    - hosts: [example.com]
+      secretName: web-tls
+  defaultBackend:
+    service:
+      name: web
+      port:
+        number: 80
+  rules:
+    - http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: web
+                port:
+                  name: https
+          - path: /static
+            pathType: Prefix
+            backend:
+              resource:
+                apiGroup: k8s.example.com
+                kind: StorageBucket
+                name: static-assets
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: legacy
+  annotations:
+    kubernetes.io/ingress.class: nginx
+spec:
+  backend:
+    serviceName: web
+    servicePort: http
+  rules:
+    - http:
+        paths:
+          - path: /
+            backend:
+              serviceName: web
+              servicePort: 8080
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+	nodes := g.Nodes()
+
+	var edges []string
+	for _, e := range g.Out(nodes[2]) {
+		edges = append(edges, string(e.Type)+" "+e.To.String()+" "+e.Field)
+	}
+	want := []string{
+		"references IngressClass/nginx spec.ingressClassName",
+		"references Secret/web-tls spec.tls[0].secretName",
+		"routes Service/web spec.defaultBackend.service.name",
+		"routes Service/web spec.rules[0].http.paths[0].backend.service.name",
+		"routes StorageBucket/static-assets spec.rules[0].http.paths[1].backend.resource.name",
+	}
+	if strings.Join(edges, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected edges:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(edges, "\n"))
+	}
+
+	tests := []struct {
+		node k8s.Node
+		want []string
+	}{
+		{nodes[2], []string{`Ingress/web: spec.rules[0].http.paths[0].backend.service.port.name: Service/web has no port "https"`}},
+		{nodes[3], []string{`Ingress/legacy: spec.rules[0].http.paths[0].backend.servicePort: Service/web has no port 8080`}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, issue := range g.IssuesFor(tt.node) {
+			got = append(got, issue.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: expected issues %v, got %v", tt.node, tt.want, got)
+		}
+	}
+
+	if got := len(g.Out(nodes[3])); got != 3 {
+		t.Errorf("Expected the legacy Ingress to have 3 edges, got %d", got)
+	}
+}
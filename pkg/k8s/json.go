@@ -0,0 +1,163 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// isJSON reports whether a manifest stream looks like JSON rather than YAML:
+// a single object, an array of objects or newline-delimited objects
+func isJSON(data []byte) bool {
+	data = bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[')
+}
+
+// splitJSON splits a JSON stream into one document per object. Top-level
+// arrays are expanded into their elements. Since JSON is valid YAML, the
+// documents are then decoded by the same code path as YAML input.
+//
+// A syntax error produces a document carrying the error; parsing resumes at
+// the next line that starts a new object in the first column, so a single
+// bad line in a JSON-lines stream does not hide the rest.
+func splitJSON(data []byte) []document {
+	lines := lineOffsets(data)
+	var docs []document
+	pos := skipJSONSpace(data, 0)
+	for pos < len(data) {
+		dec := json.NewDecoder(bytes.NewReader(data[pos:]))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			end := nextJSONValue(data, pos+1)
+			doc := lines.document(data[pos:end], pos)
+			doc.err = err
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				doc.errLine, _ = lines.position(pos + int(syntaxErr.Offset) - 1)
+			}
+			docs = append(docs, doc)
+			pos = skipJSONSpace(data, end)
+			continue
+		}
+
+		if raw[0] == '[' {
+			docs = append(docs, splitJSONArray(data, pos, lines)...)
+		} else {
+			docs = append(docs, lines.document(raw, pos))
+		}
+		pos = skipJSONSpace(data, pos+int(dec.InputOffset()))
+	}
+	return docs
+}
+
+// validJSON reports whether every document of a JSON stream was decoded
+func validJSON(docs []document) bool {
+	for _, doc := range docs {
+		if doc.err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// validYAML reports whether every document of a YAML stream parses
+func validYAML(docs []document) bool {
+	for _, doc := range docs {
+		if doc.err != nil {
+			return false
+		}
+		if _, err := parseDocument(doc.raw); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// splitJSONArray returns a document for each element of the array at start
+func splitJSONArray(data []byte, start int, lines lineIndex) []document {
+	var docs []document
+	dec := json.NewDecoder(bytes.NewReader(data[start:]))
+	if _, err := dec.Token(); err != nil {
+		return nil
+	}
+	for dec.More() {
+		off := start + int(dec.InputOffset())
+		for off < len(data) && (isJSONSpace(data[off]) || data[off] == ',') {
+			off++
+		}
+		var elem json.RawMessage
+		if err := dec.Decode(&elem); err != nil {
+			// The whole array was already validated, so this is unexpected
+			break
+		}
+		docs = append(docs, lines.document(elem, off))
+	}
+	return docs
+}
+
+// nextJSONValue returns the offset of the next line that starts with an
+// object or array in the first column at or after pos, or the end of data.
+// Indented values belong to a pretty-printed document, so a broken one
+// extends to the next top-level value.
+func nextJSONValue(data []byte, pos int) int {
+	for pos < len(data) {
+		i := bytes.IndexByte(data[pos:], '\n')
+		if i < 0 {
+			return len(data)
+		}
+		pos += i + 1
+		if pos < len(data) && (data[pos] == '{' || data[pos] == '[') {
+			return pos
+		}
+	}
+	return len(data)
+}
+
+// skipJSONSpace skips whitespace, a byte order mark and "---" separator
+// lines starting at pos
+func skipJSONSpace(data []byte, pos int) int {
+	if pos == 0 {
+		pos = len(data) - len(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	}
+	for pos < len(data) {
+		switch {
+		case isJSONSpace(data[pos]):
+			pos++
+		case bytes.HasPrefix(data[pos:], []byte("---")) && (pos == 0 || data[pos-1] == '\n'):
+			pos += 3
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// lineIndex maps byte offsets of a stream to lines and columns
+type lineIndex []int
+
+func lineOffsets(data []byte) lineIndex {
+	idx := lineIndex{0}
+	for i, c := range data {
+		if c == '\n' {
+			idx = append(idx, i+1)
+		}
+	}
+	return idx
+}
+
+// position returns the 1-based line and column of the byte at offset
+func (idx lineIndex) position(offset int) (int, int) {
+	line := sort.Search(len(idx), func(i int) bool { return idx[i] > offset })
+	return line, offset - idx[line-1] + 1
+}
+
+// document creates a document for raw starting at offset
+func (idx lineIndex) document(raw []byte, offset int) document {
+	line, column := idx.position(offset)
+	return document{raw: string(raw), line: line, column: column}
+}
@@ -0,0 +1,123 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestLoadJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		names   []string
+		errors  int
+		errLine int
+	}{
+		{
+			name: "object",
+			content: `{
+  "apiVersion": "v1",
+  "kind": "Service",
+  "metadata": {"name": "web"}
+}`,
+			names: []string{"web"},
+		},
+		{
+			name: "array",
+			content: `[
+  {"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}},
+  {"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "b"}}
+]`,
+			names: []string{"a", "b"},
+		},
+		{
+			name: "json lines with a broken line",
+			content: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}}
+{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": }
+{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "c"}}
+`,
+			names:   []string{"a", "c"},
+			errors:  1,
+			errLine: 2,
+		},
+		{
+			name: "broken pretty-printed object",
+			content: `{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {"name": "web"}
+  "spec": {
+    "template": {
+      "spec": {
+        "containers": [
+          {"name": "a", "image": "x"}
+        ]
+      }
+    }
+  }
+}
+{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "b"}}
+`,
+			names:   []string{"b"},
+			errors:  1,
+			errLine: 5,
+		},
+		{
+			name:    "flow style yaml",
+			content: "{apiVersion: v1, kind: ConfigMap, metadata: {name: a}}\n",
+			names:   []string{"a"},
+		},
+		{
+			name: "flow style yaml documents",
+			content: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}}
+---
+{apiVersion: v1, kind: ConfigMap, metadata: {name: b}}
+`,
+			names: []string{"a", "b"},
+		},
+		{
+			name:    "list",
+			content: `{"apiVersion": "v1", "kind": "List", "items": [{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "p"}}]}`,
+			names:   []string{"p"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := k8s.Load(strings.NewReader(tt.content), "input.json")
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if len(result.Errors) != tt.errors {
+				t.Errorf("Expected %d errors, got %v", tt.errors, result.Errors)
+			}
+			if tt.errLine != 0 && len(result.Errors) > 0 && result.Errors[0].Line != tt.errLine {
+				t.Errorf("Expected error on line %d, got %d", tt.errLine, result.Errors[0].Line)
+			}
+			var names []string
+			for _, res := range result.Resources {
+				names = append(names, res.Metadata.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.names, ",") {
+				t.Errorf("Expected resources %v, got %v", tt.names, names)
+			}
+		})
+	}
+}
+
+func TestLoadJSONPositions(t *testing.T) {
+	content := `[
+  {"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}},
+  {"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "b"}}
+]`
+	result, err := k8s.Load(strings.NewReader(content), "input.json")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := k8s.Source{File: "input.json", Index: 1, Line: 3, Column: 3}
+	if got := result.Resources[1].Source; got != want {
+		t.Errorf("Expected source %v, got %v", want, got)
+	}
+}
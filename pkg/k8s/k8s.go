@@ -4,240 +4,281 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"reflect"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Metadata represents Kubernetes resource metadata
 type Metadata struct {
-	Name      string            `yaml:"name"`
-	Namespace string            `yaml:"namespace,omitempty"`
-	Labels    map[string]string `yaml:"labels,omitempty"`
+	Name            string            `yaml:"name"`
+	Namespace       string            `yaml:"namespace,omitempty"`
+	UID             string            `yaml:"uid,omitempty"`
+	Labels          map[string]string `yaml:"labels,omitempty"`
+	Annotations     map[string]string `yaml:"annotations,omitempty"`
+	OwnerReferences []OwnerReference  `yaml:"ownerReferences,omitempty"`
 }
 
-// Resource represents a Kubernetes resource
+// OwnerReference identifies the object owning a resource, as set by
+// controllers on the objects they create
+type OwnerReference struct {
+	APIVersion         string `yaml:"apiVersion"`
+	Kind               string `yaml:"kind"`
+	Name               string `yaml:"name"`
+	UID                string `yaml:"uid,omitempty"`
+	Controller         bool   `yaml:"controller,omitempty"`
+	BlockOwnerDeletion bool   `yaml:"blockOwnerDeletion,omitempty"`
+}
+
+// Resource represents a Kubernetes resource.
+//
+// The typed fields are convenience accessors for the parts of the object the
+// package inspects. Object holds the complete decoded document, so fields
+// such as status or webhooks survive display and export unchanged.
 type Resource struct {
-    APIVersion string      `yaml:"apiVersion"`
-	Kind       string      `yaml:"kind"`
-	Metadata   Metadata    `yaml:"metadata"`
-	Spec       interface{} `yaml:"spec,omitempty"`
-	Data       interface{} `yaml:"data,omitempty"`
+	APIVersion string                 `yaml:"apiVersion"`
+	Kind       string                 `yaml:"kind"`
+	Metadata   Metadata               `yaml:"metadata"`
+	Spec       interface{}            `yaml:"spec,omitempty"`
+	Data       interface{}            `yaml:"data,omitempty"`
+	Object     map[string]interface{} `yaml:"-"`
+	Source     Source                 `yaml:"-"`
+	Raw        string                 `yaml:"-"` // Original document text
 }
 
-// Parse parses Kubernetes resources from an io.Reader
-func Parse(r io.Reader) ([]Resource, error) {
-	var resources []Resource
-	decoder := yaml.NewDecoder(r)
-	for {
-		var resource Resource
-		if err := decoder.Decode(&resource); err != nil {
-			if err == io.EOF {
-				break
-			}
-			return nil, fmt.Errorf("error decoding YAML: %w", err)
+// Source records where a resource was read from
+type Source struct {
+	File   string // File path, empty when read from stdin
+	Index  int    // Zero-based index of the document within the file
+	Line   int    // Line on which the document starts (1-based)
+	Column int    // Column on which the document starts (1-based)
+
+	// Template is the template that rendered the document, taken from a
+	// "# Source:" comment as written by helm template
+	Template string
+
+	// List is set when the resource was expanded from a List wrapper
+	List *ListSource
+
+	// Kustomize is set when the resource was produced by a kustomize build
+	Kustomize *KustomizeSource
+}
+
+// KustomizeSource records which files of a kustomization produced a resource
+type KustomizeSource struct {
+	Origin          string   // File that declared or generated the resource
+	Transformations []string // Kustomizations and transformers that modified it
+}
+
+// ListSource identifies the List wrapper a resource was expanded from
+type ListSource struct {
+	APIVersion string
+	Kind       string
+	Item       int // Zero-based index within the list's items
+}
+
+// String formats the source as file:line:column
+func (s Source) String() string {
+	file := s.File
+	if file == "" {
+		file = "<stdin>"
+	}
+	doc := fmt.Sprintf("doc %d", s.Index)
+	if s.Template != "" {
+		doc += ", template " + s.Template
+	}
+	if s.List != nil {
+		doc += fmt.Sprintf(", %s item %d", s.List.Kind, s.List.Item)
+	}
+	if s.Kustomize != nil && s.Kustomize.Origin != "" {
+		doc += ", from " + s.Kustomize.Origin
+		if len(s.Kustomize.Transformations) > 0 {
+			doc += " via " + strings.Join(s.Kustomize.Transformations, ", ")
 		}
-		resources = append(resources, resource)
 	}
-	return resources, nil
+	if s.Line == 0 {
+		return fmt.Sprintf("%s (%s)", file, doc)
+	}
+	return fmt.Sprintf("%s:%d:%d (%s)", file, s.Line, s.Column, doc)
+}
+
+// plainResource has the fields of Resource without its YAML methods.
+type plainResource Resource
+
+// UnmarshalYAML decodes both the typed accessors and the full object.
+func (r *Resource) UnmarshalYAML(node *yaml.Node) error {
+	var p plainResource
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	var obj map[string]interface{}
+	if err := node.Decode(&obj); err != nil {
+		return err
+	}
+	*r = Resource(p)
+	r.Object = obj
+	return nil
+}
+
+// MarshalYAML encodes the full object when one was decoded, falling back to
+// the typed fields for resources built in code.
+func (r Resource) MarshalYAML() (interface{}, error) {
+	if r.Object != nil {
+		return r.Object, nil
+	}
+	return plainResource(r), nil
+}
+
+// Get returns the value at the given path of map keys in the full object.
+func (r Resource) Get(path ...string) (interface{}, bool) {
+	var cur interface{} = r.Object
+	for _, key := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, cur != nil
+}
+
+// Parse parses Kubernetes resources from an io.Reader.
+//
+// Documents that fail to decode do not stop parsing: the valid resources are
+// returned together with a ParseErrors value describing the broken documents.
+func Parse(r io.Reader) ([]Resource, error) {
+	result, err := Load(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return result.Resources, result.Err()
 }
 
-// ParseFromFile parses Kubernetes resources from a YAML file
+// ParseFromFile parses Kubernetes resources from a YAML file.
+// Like Parse, it returns the valid resources alongside any ParseErrors.
 func ParseFromFile(filename string) ([]Resource, error) {
-	f, err := os.Open(filename)
+	result, err := LoadFile(filename)
 	if err != nil {
-		return nil, fmt.Errorf("error opening file: %w", err)
+		return nil, err
 	}
-	defer f.Close()
-	return Parse(f)
+	return result.Resources, result.Err()
+}
+
+// ExportFormat selects how a resource is rendered as text
+type ExportFormat int
+
+const (
+	// Normalized re-encodes the decoded object with sorted keys
+	Normalized ExportFormat = iota
+	// Original is the document text exactly as it was read, including
+	// comments, key order, anchors and block scalars
+	Original
+)
+
+// Text renders the resource in the given format. Resources without original
+// text, such as those built in code, are always normalized.
+func (r Resource) Text(format ExportFormat) ([]byte, error) {
+	if format == Original && r.Raw != "" {
+		return []byte(r.Raw), nil
+	}
+	yamlData, err := yaml.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling resource: %w", err)
+	}
+	return yamlData, nil
 }
 
 // Export exports a resource to a YAML file
 func Export(resource Resource) error {
+	_, err := ExportAs(resource, Normalized)
+	return err
+}
+
+// ExportAs exports a resource to a YAML file in the given format and
+// returns the name of the written file
+func ExportAs(resource Resource, format ExportFormat) (string, error) {
 	filename := fmt.Sprintf("%s-%s.yaml", resource.Kind, resource.Metadata.Name)
-	yamlData, err := yaml.Marshal(resource)
+	yamlData, err := resource.Text(format)
 	if err != nil {
-		return fmt.Errorf("error marshaling resource: %w", err)
+		return "", err
 	}
 
 	err = os.WriteFile(filename, yamlData, 0644)
 	if err != nil {
-		return fmt.Errorf("error writing file: %w", err)
+		return "", fmt.Errorf("error writing file: %w", err)
 	}
-	return nil
+	return filename, nil
 }
 
-// FindRelatedResources finds resources related to this resource
+// FindRelatedResources describes the relationships of this resource with
+// the others, e.g. "→ Selects Deployment/web" or "← Selected by Service/web".
+// Every call builds a graph of all resources.
+//
+// Deprecated: use BuildGraph and Graph.Relations, which compute the
+// relationships of a whole set once.
 func (r Resource) FindRelatedResources(allResources []Resource) []string {
-	var relations []string
-	spec := reflect.ValueOf(r.Spec)
-
-	switch r.Kind {
-	case "Service":
-		if spec.Kind() == reflect.Map {
-			selectorMap := spec.Interface().(map[string]interface{})
-			if selector, ok := selectorMap["selector"].(map[string]interface{}); ok {
-				selectorStringMap := convertToStringMap(selector)
-				// Find Deployments, StatefulSets, and Pods matching the selector
-				for _, res := range allResources {
-					if res.Kind == "Deployment" || res.Kind == "StatefulSet" || res.Kind == "Pod" {
-						if labels := getResourceLabels(res); labels != nil {
-							if matchLabels(selectorStringMap, labels) {
-								relations = append(relations, fmt.Sprintf("→ Selects %s/%s", res.Kind, res.Metadata.Name))
-							}
-						}
-					}
-				}
-			}
-		}
-
-	case "Deployment", "StatefulSet":
-		if spec.Kind() == reflect.Map {
-			specMap := spec.Interface().(map[string]interface{})
-			if template, ok := specMap["template"].(map[string]interface{}); ok {
-				if metadata, ok := template["metadata"].(map[string]interface{}); ok {
-					if labels, ok := metadata["labels"].(map[string]interface{}); ok {
-						// Find Services that select this workload
-						for _, res := range allResources {
-							if res.Kind == "Service" {
-								svcSpec := reflect.ValueOf(res.Spec)
-								if svcSpec.Kind() == reflect.Map {
-									svcSpecMap := svcSpec.Interface().(map[string]interface{})
-									if selector, ok := svcSpecMap["selector"].(map[string]interface{}); ok {
-										selectorStringMap := convertToStringMap(selector)
-										labelsStringMap := convertToStringMap(labels)
-										if matchLabels(selectorStringMap, labelsStringMap) {
-											relations = append(relations, fmt.Sprintf("← Selected by Service/%s", res.Metadata.Name))
-										}
-									}
-								}
-							}
-						}
-					}
-				}
-			}
-
-			// Check for ConfigMap/Secret references in volumes
-			if volumes, ok := findVolumes(specMap); ok {
-				for _, vol := range volumes {
-					if cm, ok := vol["configMap"].(map[string]interface{}); ok {
-						if name, ok := cm["name"].(string); ok {
-							relations = append(relations, fmt.Sprintf("→ Uses ConfigMap/%s", name))
-						}
-					}
-					if secret, ok := vol["secret"].(map[string]interface{}); ok {
-						if name, ok := secret["name"].(string); ok {
-							relations = append(relations, fmt.Sprintf("→ Uses Secret/%s", name))
-						}
-					}
-				}
-			}
-
-			// Check for Secret references in environment variables
-			if template, ok := specMap["template"].(map[string]interface{}); ok {
-				if podSpec, ok := template["spec"].(map[string]interface{}); ok {
-					if containers, ok := podSpec["containers"].([]interface{}); ok {
-						for _, c := range containers {
-							if container, ok := c.(map[string]interface{}); ok {
-								if envs, ok := container["env"].([]interface{}); ok {
-									for _, e := range envs {
-										if env, ok := e.(map[string]interface{}); ok {
-											if valueFrom, ok := env["valueFrom"].(map[string]interface{}); ok {
-												if secretRef, ok := valueFrom["secretKeyRef"].(map[string]interface{}); ok {
-													if name, ok := secretRef["name"].(string); ok {
-														relations = append(relations, fmt.Sprintf("→ Uses Secret/%s", name))
-													}
-												}
-											}
-										}
-									}
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-
-	case "Ingress":
-		if spec.Kind() == reflect.Map {
-			specMap := spec.Interface().(map[string]interface{})
-			if rules, ok := specMap["rules"].([]interface{}); ok {
-				for _, r := range rules {
-					if rule, ok := r.(map[string]interface{}); ok {
-						if http, ok := rule["http"].(map[string]interface{}); ok {
-							if paths, ok := http["paths"].([]interface{}); ok {
-								for _, p := range paths {
-									if path, ok := p.(map[string]interface{}); ok {
-										if backend, ok := path["backend"].(map[string]interface{}); ok {
-											if service, ok := backend["service"].(map[string]interface{}); ok {
-												if name, ok := service["name"].(string); ok {
-													relations = append(relations, fmt.Sprintf("→ Routes to Service/%s", name))
-												}
-											}
-										}
-									}
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-
-	case "HorizontalPodAutoscaler":
-		if spec.Kind() == reflect.Map {
-			specMap := spec.Interface().(map[string]interface{})
-			if scaleTargetRef, ok := specMap["scaleTargetRef"].(map[string]interface{}); ok {
-				if kind, ok := scaleTargetRef["kind"].(string); ok {
-					if name, ok := scaleTargetRef["name"].(string); ok {
-						relations = append(relations, fmt.Sprintf("→ Scales %s/%s", kind, name))
-					}
-				}
-			}
-		}
+	g := BuildGraph(allResources)
+	if _, ok := g.Resource(NodeOf(r)); !ok {
+		g = BuildGraph(append(allResources[:len(allResources):len(allResources)], r))
 	}
-
-	return relations
+	return g.Relations(NodeOf(r))
 }
 
 // Helper functions
 
-func findVolumes(spec map[string]interface{}) ([]map[string]interface{}, bool) {
-	if template, ok := spec["template"].(map[string]interface{}); ok {
-		if podSpec, ok := template["spec"].(map[string]interface{}); ok {
-			if volumes, ok := podSpec["volumes"].([]interface{}); ok {
-				result := make([]map[string]interface{}, len(volumes))
-				for i, v := range volumes {
-					if volMap, ok := v.(map[string]interface{}); ok {
-						result[i] = volMap
-					}
-				}
-				return result, true
-			}
-		}
+// podTemplate returns the pod template of a workload, holding the pod
+// metadata and spec, or the Pod itself. ok is false for other kinds.
+func podTemplate(res Resource) (tmpl field, ok bool) {
+	spec := res.root().get("spec")
+	switch res.Kind {
+	case "Pod":
+		return res.root(), true
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		return spec.get("template"), true
+	case "CronJob":
+		return spec.get("jobTemplate").get("spec").get("template"), true
 	}
-	return nil, false
+	return field{}, false
 }
 
-func getResourceLabels(res Resource) map[string]string {
-	switch res.Kind {
-	case "Pod":
+// podTemplateLabels returns the labels of the pods a resource runs: a Pod's
+// own labels or a workload's pod template labels. It returns nil for kinds
+// that do not run pods.
+func podTemplateLabels(res Resource) map[string]string {
+	if res.Kind == "Pod" {
+		if res.Metadata.Labels == nil {
+			return map[string]string{}
+		}
 		return res.Metadata.Labels
-	case "Deployment", "StatefulSet":
-		spec := reflect.ValueOf(res.Spec)
-		if spec.Kind() == reflect.Map {
-			specMap := spec.Interface().(map[string]interface{})
-			if template, ok := specMap["template"].(map[string]interface{}); ok {
-				if metadata, ok := template["metadata"].(map[string]interface{}); ok {
-					if labels, ok := metadata["labels"].(map[string]interface{}); ok {
-						return convertToStringMap(labels)
-					}
-				}
-			}
+	}
+	tmpl, ok := podTemplate(res)
+	if !ok {
+		return nil
+	}
+	if labels := tmpl.get("metadata").get("labels").stringMap(); labels != nil {
+		return labels
+	}
+	return map[string]string{}
+}
+
+// dataKeys returns the keys a ConfigMap (data and binaryData) or Secret
+// (data and stringData) provides
+func dataKeys(res Resource) map[string]bool {
+	fields := []string{"data", "binaryData"}
+	if res.Kind == "Secret" {
+		fields = []string{"data", "stringData"}
+	}
+	keys := make(map[string]bool)
+	root := res.root()
+	for _, name := range fields {
+		m, _ := root.get(name).value.(map[string]interface{})
+		for k := range m {
+			keys[k] = true
 		}
 	}
-	return nil
+	return keys
 }
 
 func matchLabels(selector, labels map[string]string) bool {
@@ -3,8 +3,12 @@ package k8s_test
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"gopkg.in/yaml.v3"
+
 	"k8spreview/pkg/k8s"
 )
 
@@ -77,6 +81,15 @@ spec:
         if resources[1].APIVersion != "apps/v1" {
                 t.Errorf("Expected API version apps/v1, got %s", resources[1].APIVersion)
         }
+
+	// Check source positions
+	want := k8s.Source{File: tmpFile, Index: 1, Line: 10, Column: 1}
+	if resources[1].Source != want {
+		t.Errorf("Expected source %v, got %v", want, resources[1].Source)
+	}
+	if resources[0].Source.Line != 1 || resources[0].Source.Index != 0 {
+		t.Errorf("Expected first document at line 1, got %v", resources[0].Source)
+	}
 }
 
 func TestFindRelatedResources(t *testing.T) {
@@ -139,3 +152,98 @@ func TestFindRelatedResources(t *testing.T) {
 		t.Error("Deployment should be found by Service")
 	}
 }
+
+func TestParsePreservesAllFields(t *testing.T) {
+	content := `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: reader
+  namespace: default
+  annotations:
+    team: platform
+  ownerReferences:
+    - kind: Deployment
+      name: owner
+rules:
+  - apiGroups: [""]
+    resources: ["pods"]
+    verbs: ["get", "list"]
+status:
+  phase: Active`
+
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(resources) != 1 {
+		t.Fatalf("Expected 1 resource, got %d", len(resources))
+	}
+	res := resources[0]
+	if res.Metadata.Annotations["team"] != "platform" {
+		t.Errorf("Expected annotation team=platform, got %v", res.Metadata.Annotations)
+	}
+	if _, ok := res.Get("rules"); !ok {
+		t.Error("Expected rules to be preserved")
+	}
+	if phase, _ := res.Get("status", "phase"); phase != "Active" {
+		t.Errorf("Expected status.phase Active, got %v", phase)
+	}
+
+	// Round-trip through YAML and make sure nothing was dropped
+	out, err := yaml.Marshal(res)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var roundTrip k8s.Resource
+	if err := yaml.Unmarshal(out, &roundTrip); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(res.Object, roundTrip.Object) {
+		t.Errorf("Round-trip changed the object:\n%s", out)
+	}
+	if _, ok := roundTrip.Get("metadata", "ownerReferences"); !ok {
+		t.Error("Expected ownerReferences to survive round-trip")
+	}
+}
+
+func TestResourceText(t *testing.T) {
+	content := `# Application settings
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings # inline comment
+data:
+  z-last: "1"
+  a-first: "2"
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	original, err := resources[0].Text(k8s.Original)
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if string(original) != content {
+		t.Errorf("Expected original text verbatim, got:\n%s", original)
+	}
+
+	normalized, err := resources[0].Text(k8s.Normalized)
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if strings.Contains(string(normalized), "#") {
+		t.Errorf("Expected normalized text without comments, got:\n%s", normalized)
+	}
+	if strings.Index(string(normalized), "a-first") > strings.Index(string(normalized), "z-last") {
+		t.Errorf("Expected normalized text with sorted keys, got:\n%s", normalized)
+	}
+
+	// Resources built in code have no original text
+	built := k8s.Resource{APIVersion: "v1", Kind: "ConfigMap", Metadata: k8s.Metadata{Name: "built"}}
+	text, err := built.Text(k8s.Original)
+	if err != nil || !strings.Contains(string(text), "name: built") {
+		t.Errorf("Expected normalized fallback, got %q (%v)", text, err)
+	}
+}
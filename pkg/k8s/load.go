@@ -0,0 +1,403 @@
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Result is the outcome of leniently parsing one or more manifest streams
+type Result struct {
+	Resources []Resource
+	Errors    ParseErrors
+	Others    []Document // Valid YAML that is not a Kubernetes object
+}
+
+// Document is a YAML document that is not a Kubernetes object, such as Helm
+// NOTES output or arbitrary configuration
+type Document struct {
+	Source Source
+	Raw    string
+}
+
+// Add appends the contents of another result
+func (r *Result) Add(other *Result) {
+	if other == nil {
+		return
+	}
+	r.Resources = append(r.Resources, other.Resources...)
+	r.Errors = append(r.Errors, other.Errors...)
+	r.Others = append(r.Others, other.Others...)
+}
+
+// Err returns the collected document errors, or nil if there were none
+func (r *Result) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors
+}
+
+// DocumentError describes a single document that could not be parsed
+type DocumentError struct {
+	Source Source // Where the document starts
+	Line   int    // Line of the error itself, if known
+	Raw    string // Original text of the document
+	Err    error
+
+	offset int // Line of the stream on which the decoder's line 1 falls
+}
+
+// Error implements the error interface, reporting lines relative to the file
+func (e *DocumentError) Error() string {
+	msg := yamlLineRe.ReplaceAllStringFunc(e.Err.Error(), func(m string) string {
+		n, _ := strconv.Atoi(yamlLineRe.FindStringSubmatch(m)[1])
+		return fmt.Sprintf("line %d", n+e.offset-1)
+	})
+	return fmt.Sprintf("%s: %s", e.Source, msg)
+}
+
+// Unwrap returns the underlying decoding error
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+// ParseErrors collects the errors of every document that failed to parse
+type ParseErrors []*DocumentError
+
+// Error implements the error interface
+func (e ParseErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d documents failed to parse:\n  %s", len(e), strings.Join(msgs, "\n  "))
+}
+
+// yamlLineRe matches the document-relative line numbers in yaml.v3 errors
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+// Load parses every document in r, recording file as the source of each.
+// The input may be a multi-document YAML stream, a JSON object, a JSON array
+// of objects or newline-delimited JSON objects.
+// Broken documents are collected in Result.Errors instead of aborting the
+// stream; the returned error is only set when r itself cannot be read.
+func Load(r io.Reader, file string) (*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading input: %w", err)
+	}
+
+	var docs []document
+	if isJSON(data) {
+		docs = splitJSON(data)
+		// Flow-style YAML also starts with { or [, so broken JSON is
+		// reparsed as YAML. The errors are kept if that fails too, or if
+		// YAML sees fewer documents, as for JSON lines with a broken line.
+		if !validJSON(docs) {
+			if yamlDocs := splitDocuments(data); len(yamlDocs) >= len(docs) && validYAML(yamlDocs) {
+				docs = yamlDocs
+			}
+		}
+	} else {
+		docs = splitDocuments(data)
+	}
+
+	result := &Result{}
+	for index, doc := range docs {
+		src := Source{File: file, Index: index, Line: doc.line, Column: doc.column, Template: templateSource(doc.raw)}
+		if doc.err != nil {
+			result.Errors = append(result.Errors, newDocumentError(src, doc, doc.err))
+			continue
+		}
+		root, err := parseDocument(doc.raw)
+		if err != nil {
+			result.Errors = append(result.Errors, newDocumentError(src, doc, err))
+			continue
+		}
+		src.Line, src.Column = doc.position(root)
+
+		// Empty documents (stray separators, comments only) are dropped and
+		// anything without kind and apiVersion is kept aside as foreign YAML
+		if isEmptyDocument(root) {
+			continue
+		}
+		if !isKubernetesObject(root) {
+			result.Others = append(result.Others, Document{Source: src, Raw: doc.raw})
+			continue
+		}
+
+		var res Resource
+		if err := root.Decode(&res); err != nil {
+			result.Errors = append(result.Errors, newDocumentError(src, doc, err))
+			continue
+		}
+		res.Source = src
+		res.Raw = doc.raw
+
+		if items := listItems(res, root); items != nil {
+			result.addListItems(res, items, doc)
+			continue
+		}
+		result.Resources = append(result.Resources, res)
+	}
+	return result, nil
+}
+
+// addListItems flattens the items of a List wrapper into individual resources
+func (r *Result) addListItems(list Resource, items []*yaml.Node, doc document) {
+	for i, node := range items {
+		src := list.Source
+		src.Line, src.Column = doc.position(node)
+		src.List = &ListSource{APIVersion: list.APIVersion, Kind: list.Kind, Item: i}
+
+		var res Resource
+		if err := node.Decode(&res); err != nil {
+			raw, _ := yaml.Marshal(node)
+			r.Errors = append(r.Errors, newDocumentError(src, document{raw: string(raw), line: doc.line, column: doc.column}, err))
+			continue
+		}
+		// Typed lists such as DeploymentList may omit kind and apiVersion on items
+		if res.Kind == "" && list.Kind != "List" {
+			res.Kind = strings.TrimSuffix(list.Kind, "List")
+			if res.Object != nil {
+				res.Object["kind"] = res.Kind
+			}
+		}
+		if res.APIVersion == "" && list.Kind != "List" {
+			res.APIVersion = list.APIVersion
+			if res.Object != nil {
+				res.Object["apiVersion"] = res.APIVersion
+			}
+		}
+		// Items are re-encoded from their node, which keeps comments and
+		// key order but not the exact indentation of the list
+		raw, _ := yaml.Marshal(node)
+		res.Source = src
+		res.Raw = string(raw)
+		if res.Kind == "" || res.APIVersion == "" {
+			r.Others = append(r.Others, Document{Source: src, Raw: res.Raw})
+			continue
+		}
+		r.Resources = append(r.Resources, res)
+	}
+}
+
+// templateSource returns the template named by a "# Source:" comment in the
+// leading comments of a document, as written by helm template
+func templateSource(raw string) string {
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		if rest := strings.TrimSpace(strings.TrimPrefix(line, "#")); strings.HasPrefix(rest, "Source:") {
+			return strings.TrimSpace(strings.TrimPrefix(rest, "Source:"))
+		}
+	}
+	return ""
+}
+
+// isEmptyDocument reports whether a document has no content besides comments
+func isEmptyDocument(root *yaml.Node) bool {
+	switch root.Kind {
+	case 0, yaml.DocumentNode:
+		return len(root.Content) == 0
+	case yaml.ScalarNode:
+		return root.Tag == "!!null"
+	}
+	return false
+}
+
+// isKubernetesObject reports whether a document is a mapping with both a
+// kind and an apiVersion
+func isKubernetesObject(root *yaml.Node) bool {
+	if root.Kind != yaml.MappingNode {
+		return false
+	}
+	var hasKind, hasAPIVersion bool
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		if value.Kind != yaml.ScalarNode || value.Value == "" {
+			continue
+		}
+		switch key {
+		case "kind":
+			hasKind = true
+		case "apiVersion":
+			hasAPIVersion = true
+		}
+	}
+	return hasKind && hasAPIVersion
+}
+
+// listItems returns the item nodes of a List or *List wrapper, or nil if the
+// resource is not a list
+func listItems(res Resource, root *yaml.Node) []*yaml.Node {
+	if !strings.HasSuffix(res.Kind, "List") || root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "items" {
+			if items := root.Content[i+1]; items.Kind == yaml.SequenceNode {
+				return append([]*yaml.Node{}, items.Content...)
+			}
+		}
+	}
+	return nil
+}
+
+// newDocumentError creates a DocumentError, translating the line reported by
+// the YAML decoder into a line of the whole stream
+func newDocumentError(src Source, doc document, err error) *DocumentError {
+	docErr := &DocumentError{Source: src, Line: src.Line, Raw: doc.raw, Err: err, offset: doc.line}
+	if doc.errLine != 0 {
+		docErr.Line = doc.errLine
+	} else if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		docErr.Line = n + doc.line - 1
+	}
+	return docErr
+}
+
+// LoadFile leniently parses the documents in a file, see Load
+func LoadFile(filename string) (*Result, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+	return Load(f, filename)
+}
+
+// document is the raw text of a single YAML or JSON document in a stream
+type document struct {
+	raw    string
+	line   int // Line of the stream on which raw starts (1-based)
+	column int // Column of the stream on which raw starts (1-based)
+
+	err     error // Set when the document could not be split out cleanly
+	errLine int   // Line of the stream on which err occurred, if known
+}
+
+// position translates the position of a node within the document into a
+// line and column of the whole stream
+func (d document) position(node *yaml.Node) (int, int) {
+	column := node.Column
+	if node.Line == 1 && d.column > 1 {
+		column += d.column - 1
+	}
+	return node.Line + d.line - 1, column
+}
+
+// parseDocument parses a single document and returns its root node
+func parseDocument(raw string) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) > 0 {
+		return node.Content[0], nil
+	}
+	return &node, nil
+}
+
+// splitDocuments splits a multi-document YAML stream on its "---" and "..."
+// markers so that a syntax error in one document cannot affect the others.
+// Documents consisting only of whitespace are dropped, as is a comment
+// header before the first "---" marker.
+func splitDocuments(data []byte) []document {
+	var docs []document
+	var cur strings.Builder
+	start := 1
+	started := false
+	flush := func() {
+		if strings.TrimSpace(cur.String()) != "" {
+			docs = append(docs, document{raw: cur.String(), line: start, column: 1})
+		}
+		cur.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		marker := strings.TrimSuffix(line, "\r")
+		switch {
+		case marker == "..." || strings.HasPrefix(marker, "... "):
+			flush()
+			start = lineNo + 1
+		case isDocumentStart(marker):
+			if isDirectivesOnly(cur.String()) {
+				// Directives belong to the document they introduce
+				cur.WriteString(line + "\n")
+				continue
+			}
+			if !started && isCommentsOnly(cur.String()) {
+				cur.Reset()
+			}
+			started = true
+			flush()
+			start = lineNo + 1
+			if rest := strings.TrimSpace(marker[3:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				// Content on the marker line, e.g. "--- !tag" or "--- |"
+				start = lineNo
+				cur.WriteString(line + "\n")
+			}
+		default:
+			if cur.Len() == 0 && strings.TrimSpace(line) == "" {
+				start = lineNo + 1
+				continue
+			}
+			cur.WriteString(line + "\n")
+		}
+	}
+	flush()
+	return docs
+}
+
+// isDocumentStart reports whether a line is a "---" document marker
+func isDocumentStart(line string) bool {
+	return line == "---" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "---\t")
+}
+
+// isCommentsOnly reports whether raw holds nothing but blank lines and
+// comments
+func isCommentsOnly(raw string) bool {
+	for _, line := range strings.Split(raw, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// isDirectivesOnly reports whether raw holds %YAML or %TAG directives and
+// nothing but blank lines and comments besides
+func isDirectivesOnly(raw string) bool {
+	found := false
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "%") {
+			return false
+		}
+		found = true
+	}
+	return found
+}
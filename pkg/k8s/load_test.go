@@ -0,0 +1,176 @@
+package k8s_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestLoadRecoversFromBrokenDocuments(t *testing.T) {
+	content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: broken
+  labels: [unclosed
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+`
+	result, err := k8s.Load(strings.NewReader(content), "bundle.yaml")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(result.Resources) != 2 {
+		t.Fatalf("Expected 2 valid resources, got %d", len(result.Resources))
+	}
+	if result.Resources[1].Metadata.Name != "second" || result.Resources[1].Source.Line != 12 {
+		t.Errorf("Unexpected second resource: %s at %v", result.Resources[1].Metadata.Name, result.Resources[1].Source)
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 document error, got %d", len(result.Errors))
+	}
+	docErr := result.Errors[0]
+	if docErr.Source.File != "bundle.yaml" || docErr.Source.Index != 1 || docErr.Source.Line != 6 {
+		t.Errorf("Unexpected error source: %v", docErr.Source)
+	}
+	if docErr.Line < 6 || docErr.Line > 10 {
+		t.Errorf("Expected error line to be relative to the file, got %d", docErr.Line)
+	}
+	if !strings.Contains(docErr.Error(), fmt.Sprintf("line %d", docErr.Line)) {
+		t.Errorf("Expected error message to use file lines, got %q", docErr.Error())
+	}
+	if !strings.Contains(docErr.Raw, "name: broken") {
+		t.Errorf("Expected raw text of the broken document, got %q", docErr.Raw)
+	}
+
+	// Parse returns the valid resources together with the errors
+	resources, err := k8s.Parse(strings.NewReader(content))
+	var parseErrs k8s.ParseErrors
+	if !errors.As(err, &parseErrs) || len(parseErrs) != 1 {
+		t.Errorf("Expected ParseErrors from Parse, got %v", err)
+	}
+	if len(resources) != 2 {
+		t.Errorf("Expected Parse to keep 2 resources, got %d", len(resources))
+	}
+}
+
+func TestLoadSkipsLeadingComments(t *testing.T) {
+	content := `# Generated by the release pipeline
+# Do not edit
+
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+# Only a comment
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+`
+	result, err := k8s.Load(strings.NewReader(content), "bundle.yaml")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(result.Resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %d", len(result.Resources))
+	}
+	if got := result.Resources[0].Source; got.Index != 0 || got.Line != 5 {
+		t.Errorf("Expected the first resource to be document 0 at line 5, got %v", got)
+	}
+	// A comment-only document after the first marker still counts
+	if got := result.Resources[1].Source; got.Index != 2 || got.Line != 12 {
+		t.Errorf("Expected the second resource to be document 2 at line 12, got %v", got)
+	}
+}
+
+func TestLoadExpandsLists(t *testing.T) {
+	content := `apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Service
+    metadata:
+      name: web
+  - apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: web
+---
+apiVersion: apps/v1
+kind: DeploymentList
+items:
+  - metadata:
+      name: api
+`
+	result, err := k8s.Load(strings.NewReader(content), "")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(result.Resources) != 3 {
+		t.Fatalf("Expected 3 resources, got %d", len(result.Resources))
+	}
+
+	svc := result.Resources[0]
+	if svc.Kind != "Service" || svc.Source.List == nil || svc.Source.List.Kind != "List" || svc.Source.List.Item != 0 {
+		t.Errorf("Unexpected first item: %s %v", svc.Kind, svc.Source)
+	}
+	if svc.Source.Line != 4 {
+		t.Errorf("Expected first item at line 4, got %d", svc.Source.Line)
+	}
+
+	api := result.Resources[2]
+	if api.Kind != "Deployment" || api.APIVersion != "apps/v1" {
+		t.Errorf("Expected kind and apiVersion from DeploymentList, got %s %s", api.APIVersion, api.Kind)
+	}
+	if api.Source.Index != 1 || api.Source.List.Kind != "DeploymentList" {
+		t.Errorf("Unexpected provenance: %v", api.Source)
+	}
+}
+
+func TestLoadClassifiesDocuments(t *testing.T) {
+	content := `---
+# Only a comment
+---
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: real
+---
+NOTES: |
+  Thank you for installing the chart.
+---
+- just
+- a list
+`
+	result, err := k8s.Load(strings.NewReader(content), "")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("Expected no errors, got %v", result.Errors)
+	}
+	if len(result.Resources) != 1 || result.Resources[0].Metadata.Name != "real" {
+		t.Errorf("Expected only ConfigMap/real, got %v", result.Resources)
+	}
+	if len(result.Others) != 2 {
+		t.Fatalf("Expected 2 other documents, got %d", len(result.Others))
+	}
+	if !strings.Contains(result.Others[0].Raw, "NOTES") || result.Others[0].Source.Line != 10 {
+		t.Errorf("Unexpected first other document at %v: %q", result.Others[0].Source, result.Others[0].Raw)
+	}
+}
@@ -0,0 +1,426 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Traffic describes the connections NetworkPolicies allow from the pods of
+// one workload to the pods of another
+type Traffic struct {
+	From, To Node
+	Ports    []string // Allowed ports such as TCP/8080, nil if all ports are allowed
+	Egress   []Node   // Policies admitting the traffic at From, none if From is not isolated
+	Ingress  []Node   // Policies admitting the traffic at To, none if To is not isolated
+}
+
+// AllPorts reports whether traffic is allowed on every port
+func (t Traffic) AllPorts() bool {
+	return t.Ports == nil
+}
+
+// Network is the result of evaluating the loaded NetworkPolicies against the
+// loaded workloads and pods
+type Network struct {
+	Workloads []Node // Workloads and pods, in load order
+	allowed   map[[2]key]Traffic
+}
+
+// Allowed returns the traffic allowed from one workload to another
+func (n *Network) Allowed(from, to Node) (Traffic, bool) {
+	t, ok := n.allowed[[2]key{from.key(), to.key()}]
+	return t, ok
+}
+
+// Ingress returns the traffic other workloads may send to w
+func (n *Network) Ingress(w Node) []Traffic {
+	var traffic []Traffic
+	for _, from := range n.Workloads {
+		if t, ok := n.Allowed(from, w); ok && from != w {
+			traffic = append(traffic, t)
+		}
+	}
+	return traffic
+}
+
+// Egress returns the traffic w may send to other workloads
+func (n *Network) Egress(w Node) []Traffic {
+	var traffic []Traffic
+	for _, to := range n.Workloads {
+		if t, ok := n.Allowed(w, to); ok && to != w {
+			traffic = append(traffic, t)
+		}
+	}
+	return traffic
+}
+
+// workload is a resource running pods, as seen by NetworkPolicies
+type workload struct {
+	node   Node
+	labels map[string]string
+	ports  map[string]int // Named container ports by protocol/name, e.g. TCP/http → 8080
+}
+
+// networkPolicy is a parsed NetworkPolicy
+type networkPolicy struct {
+	node            Node
+	selector        *LabelSelector
+	ingress, egress bool // Policy types
+	ingressRules    []policyRule
+	egressRules     []policyRule
+}
+
+// policyRule is an ingress or egress rule. nil peers or ports allow all.
+type policyRule struct {
+	peers []policyPeer
+	ports []policyPort
+}
+
+// policyPeer selects the pods a rule applies to. A nil namespaces selector
+// means the policy's own namespace.
+type policyPeer struct {
+	pods       *LabelSelector
+	namespaces *LabelSelector
+	ipBlock    bool
+}
+
+// policyPort is a port, named port or port range of a rule
+type policyPort struct {
+	protocol string
+	port     string // Number or name, empty for all ports of the protocol
+	endPort  int
+}
+
+// Network evaluates the podSelector, namespaceSelector, ingress and egress
+// rules and ports of the loaded NetworkPolicies for every pair of loaded
+// workloads. Namespaces are matched by the labels of loaded Namespace
+// resources and the kubernetes.io/metadata.name label every namespace has.
+// ipBlock peers never match a workload.
+func (g *Graph) Network() *Network {
+	var workloads []workload
+	for _, n := range g.nodes {
+		res := g.index.byKey[n.key()]
+		if labels, ok := g.index.podLabels[res]; ok {
+			workloads = append(workloads, workload{node: n, labels: labels, ports: namedPorts(*res)})
+		}
+	}
+	nsLabels := g.namespaceLabels()
+	var policies []networkPolicy
+	for _, n := range g.nodes {
+		if n.Kind == "NetworkPolicy" {
+			policies = append(policies, g.parseNetworkPolicy(n))
+		}
+	}
+
+	network := &Network{allowed: make(map[[2]key]Traffic)}
+	for _, w := range workloads {
+		network.Workloads = append(network.Workloads, w.node)
+	}
+	for _, src := range workloads {
+		for _, dst := range workloads {
+			egressPorts, egress, ok := allowedBy(policies, src, dst, dst, nsLabels, false)
+			if !ok {
+				continue
+			}
+			ingressPorts, ingress, ok := allowedBy(policies, dst, src, dst, nsLabels, true)
+			if !ok {
+				continue
+			}
+			ports, ok := intersectPorts(egressPorts, ingressPorts)
+			if !ok {
+				continue
+			}
+			network.allowed[[2]key{src.node.key(), dst.node.key()}] = Traffic{
+				From: src.node, To: dst.node, Ports: ports, Egress: egress, Ingress: ingress,
+			}
+		}
+	}
+	return network
+}
+
+// allowedBy evaluates the policies isolating w in one direction against
+// peer. It returns the allowed ports (nil for all), the policies admitting
+// the traffic and whether any traffic is allowed. Named ports are resolved
+// against the destination dst.
+func allowedBy(policies []networkPolicy, w, peer, dst workload, nsLabels func(string) map[string]string, ingress bool) ([]string, []Node, bool) {
+	isolated := false
+	var ports []string
+	var admitting []Node
+	allPorts := false
+	for _, p := range policies {
+		if p.node.Namespace != w.node.Namespace || !p.selector.Matches(w.labels) {
+			continue
+		}
+		rules := p.egressRules
+		if ingress {
+			if !p.ingress {
+				continue
+			}
+			rules = p.ingressRules
+		} else if !p.egress {
+			continue
+		}
+		isolated = true
+		for _, rule := range rules {
+			if !rule.matches(peer, p.node.Namespace, nsLabels) {
+				continue
+			}
+			admitting = appendNode(admitting, p.node)
+			if rule.ports == nil {
+				allPorts = true
+			}
+			for _, port := range rule.ports {
+				ports = append(ports, port.resolve(dst.ports))
+			}
+		}
+	}
+	switch {
+	case !isolated:
+		return nil, nil, true
+	case len(admitting) == 0:
+		return nil, nil, false
+	case allPorts:
+		return nil, admitting, true
+	}
+	return dedupe(ports), admitting, true
+}
+
+// matches reports whether a rule's peers include w
+func (r policyRule) matches(w workload, namespace string, nsLabels func(string) map[string]string) bool {
+	if r.peers == nil {
+		return true
+	}
+	for _, peer := range r.peers {
+		if peer.ipBlock {
+			continue
+		}
+		if peer.namespaces == nil {
+			if w.node.Namespace != namespace {
+				continue
+			}
+		} else if !peer.namespaces.Matches(nsLabels(w.node.Namespace)) {
+			continue
+		}
+		if peer.pods == nil || peer.pods.Matches(w.labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve formats a port as protocol/port, resolving a named port against
+// the destination's container ports of the same protocol. A name no such
+// port has stays unresolved.
+func (p policyPort) resolve(named map[string]int) string {
+	switch {
+	case p.port == "":
+		return p.protocol + "/*"
+	case p.endPort != 0:
+		return fmt.Sprintf("%s/%s-%d", p.protocol, p.port, p.endPort)
+	}
+	if _, err := strconv.Atoi(p.port); err != nil {
+		if number, ok := named[p.protocol+"/"+p.port]; ok {
+			return fmt.Sprintf("%s/%d", p.protocol, number)
+		}
+	}
+	return p.protocol + "/" + p.port
+}
+
+// parseNetworkPolicy reads the selectors and rules of a NetworkPolicy.
+// Missing or invalid selectors select nothing; analyze records the latter
+// as issues.
+func (g *Graph) parseNetworkPolicy(n Node) networkPolicy {
+	spec := g.index.byKey[n.key()].root().get("spec")
+	p := networkPolicy{node: n}
+	p.selector, _ = ParseLabelSelector(spec.get("podSelector").value)
+
+	p.ingressRules = parseRules(spec.get("ingress"), "from")
+	p.egressRules = parseRules(spec.get("egress"), "to")
+	if types := spec.get("policyTypes").items(); len(types) > 0 {
+		for _, t := range types {
+			switch t.str() {
+			case "Ingress":
+				p.ingress = true
+			case "Egress":
+				p.egress = true
+			}
+		}
+	} else {
+		p.ingress = true
+		p.egress = len(p.egressRules) > 0
+	}
+	return p
+}
+
+// parseRules reads the ingress or egress rules of a NetworkPolicy
+func parseRules(f field, peersKey string) []policyRule {
+	var rules []policyRule
+	for _, r := range f.items() {
+		var rule policyRule
+		if peers := r.get(peersKey).items(); len(peers) > 0 {
+			rule.peers = []policyPeer{}
+			for _, peer := range peers {
+				pp := policyPeer{ipBlock: peer.get("ipBlock").value != nil}
+				pp.pods, _ = ParseLabelSelector(peer.get("podSelector").value)
+				pp.namespaces, _ = ParseLabelSelector(peer.get("namespaceSelector").value)
+				if pp.pods == nil && pp.namespaces == nil && !pp.ipBlock {
+					continue // An empty or invalid peer matches nothing
+				}
+				rule.peers = append(rule.peers, pp)
+			}
+		}
+		for _, port := range r.get("ports").items() {
+			pp := policyPort{protocol: port.get("protocol").str()}
+			if pp.protocol == "" {
+				pp.protocol = "TCP"
+			}
+			switch v := port.get("port").value.(type) {
+			case int:
+				pp.port = strconv.Itoa(v)
+			case string:
+				pp.port = v
+			}
+			if end, ok := port.get("endPort").value.(int); ok {
+				pp.endPort = end
+			}
+			rule.ports = append(rule.ports, pp)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// namespaceLabels returns a lookup of the labels of a namespace
+func (g *Graph) namespaceLabels() func(string) map[string]string {
+	return func(ns string) map[string]string {
+		labels := map[string]string{"kubernetes.io/metadata.name": ns}
+		if res, ok := g.index.byKey[Node{Kind: "Namespace", Name: ns}.key()]; ok {
+			for k, v := range res.Metadata.Labels {
+				labels[k] = v
+			}
+		}
+		return labels
+	}
+}
+
+// namedPorts returns the numbers of the named container ports of a
+// workload's pods, keyed by protocol/name
+func namedPorts(r Resource) map[string]int {
+	ports := make(map[string]int)
+	for _, p := range containerPorts(r) {
+		if p.name != "" {
+			ports[p.protocol+"/"+p.name] = p.port
+		}
+	}
+	return ports
+}
+
+// intersectPorts combines the ports allowed on the egress and ingress side,
+// where nil means all ports. Whole-protocol entries such as TCP/* admit every
+// port of that protocol from the other side, and ranges such as
+// TCP/8000-9000 are intersected numerically.
+func intersectPorts(a, b []string) ([]string, bool) {
+	switch {
+	case a == nil:
+		return b, true
+	case b == nil:
+		return a, true
+	}
+	var ports []string
+	for _, x := range a {
+		for _, y := range b {
+			if p, ok := intersectPort(parsePort(x), parsePort(y)); ok {
+				ports = append(ports, p)
+			}
+		}
+	}
+	ports = dedupe(ports)
+	return ports, len(ports) > 0
+}
+
+// portRange is a parsed protocol/port entry. A named port that did not
+// resolve to a number keeps its name; all is set for protocol/*.
+type portRange struct {
+	protocol   string
+	start, end int
+	name       string
+	all        bool
+}
+
+// parsePort parses TCP/*, TCP/8080, TCP/8000-9000 or TCP/http
+func parsePort(s string) portRange {
+	protocol, port, _ := strings.Cut(s, "/")
+	p := portRange{protocol: protocol}
+	if port == "*" {
+		p.all = true
+		return p
+	}
+	first, last, isRange := strings.Cut(port, "-")
+	start, err := strconv.Atoi(first)
+	if err != nil {
+		p.name = port
+		return p
+	}
+	p.start, p.end = start, start
+	if end, err := strconv.Atoi(last); isRange && err == nil {
+		p.end = end
+	}
+	return p
+}
+
+// String formats the range back as protocol/port
+func (p portRange) String() string {
+	switch {
+	case p.all:
+		return p.protocol + "/*"
+	case p.name != "":
+		return p.protocol + "/" + p.name
+	case p.start == p.end:
+		return fmt.Sprintf("%s/%d", p.protocol, p.start)
+	}
+	return fmt.Sprintf("%s/%d-%d", p.protocol, p.start, p.end)
+}
+
+// intersectPort returns the ports both x and y allow, if any. Named ports
+// only match the same name.
+func intersectPort(x, y portRange) (string, bool) {
+	switch {
+	case x.protocol != y.protocol:
+		return "", false
+	case x.all:
+		return y.String(), true
+	case y.all:
+		return x.String(), true
+	case x.name != "" || y.name != "":
+		return x.String(), x.name == y.name
+	}
+	r := portRange{protocol: x.protocol, start: max(x.start, y.start), end: min(x.end, y.end)}
+	if r.start > r.end {
+		return "", false
+	}
+	return r.String(), true
+}
+
+// dedupe sorts and removes duplicate strings
+func dedupe(values []string) []string {
+	sort.Strings(values)
+	out := values[:0]
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// appendNode appends n unless it is already present
+func appendNode(nodes []Node, n Node) []Node {
+	for _, existing := range nodes {
+		if existing == n {
+			return nodes
+		}
+	}
+	return append(nodes, n)
+}
@@ -0,0 +1,273 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestNetwork(t *testing.T) {
+	content := `apiVersion: v1
+kind: Namespace
+metadata:
+  name: monitoring
+  labels:
+    team: observability
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels:
+        app: frontend
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+        - name: api
+          ports:
+            - name: http
+              containerPort: 8080
+            - name: metrics
+              containerPort: 9090
+            - name: dns
+              containerPort: 53
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels:
+        app: db
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prometheus
+  namespace: monitoring
+spec:
+  template:
+    metadata:
+      labels:
+        app: prometheus
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: resolver
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels:
+        app: resolver
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+  namespace: shop
+spec:
+  podSelector: {}
+  policyTypes: [Ingress]
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: api
+  namespace: shop
+spec:
+  podSelector:
+    matchLabels:
+      app: api
+  ingress:
+    - from:
+        - podSelector:
+            matchLabels:
+              app: frontend
+      ports:
+        - port: http
+    - from:
+        - namespaceSelector:
+            matchLabels:
+              team: observability
+      ports:
+        - port: metrics
+    - from:
+        - podSelector:
+            matchLabels:
+              app: resolver
+      ports:
+        - protocol: UDP
+          port: dns
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: db
+  namespace: shop
+spec:
+  podSelector:
+    matchLabels:
+      app: db
+  ingress:
+    - from:
+        - podSelector:
+            matchLabels:
+              app: api
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: frontend-egress
+  namespace: shop
+spec:
+  podSelector:
+    matchLabels:
+      app: frontend
+  policyTypes: [Egress]
+  egress:
+    - to:
+        - podSelector:
+            matchExpressions:
+              - {key: app, operator: In, values: [api, db]}
+      ports:
+        - protocol: TCP
+          port: 8080
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	network := k8s.BuildGraph(resources).Network()
+	if len(network.Workloads) != 5 {
+		t.Fatalf("Expected 5 workloads, got %v", network.Workloads)
+	}
+	frontend, api, db, prometheus, resolver := network.Workloads[0], network.Workloads[1], network.Workloads[2], network.Workloads[3], network.Workloads[4]
+
+	tests := []struct {
+		name     string
+		from, to k8s.Node
+		allowed  bool
+		ports    string
+	}{
+		{"frontend to api on the named port", frontend, api, true, "TCP/8080"},
+		{"frontend egress does not cover db ingress", frontend, db, false, ""},
+		{"api to db on all ports", api, db, true, "all"},
+		{"db to api is denied", db, api, false, ""},
+		{"prometheus to api metrics through namespaceSelector", prometheus, api, true, "TCP/9090"},
+		{"prometheus to frontend is denied by default-deny", prometheus, frontend, false, ""},
+		{"api to prometheus is not isolated", api, prometheus, true, "all"},
+		{"named port of another protocol stays unresolved", resolver, api, true, "UDP/dns"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traffic, ok := network.Allowed(tt.from, tt.to)
+			if ok != tt.allowed {
+				t.Fatalf("Expected allowed=%v, got %v", tt.allowed, ok)
+			}
+			if !ok {
+				return
+			}
+			ports := strings.Join(traffic.Ports, ",")
+			if traffic.AllPorts() {
+				ports = "all"
+			}
+			if ports != tt.ports {
+				t.Errorf("Expected ports %s, got %s", tt.ports, ports)
+			}
+		})
+	}
+
+	var from []string
+	for _, tr := range network.Ingress(api) {
+		from = append(from, tr.From.Name)
+	}
+	if strings.Join(from, ",") != "frontend,prometheus,resolver" {
+		t.Errorf("Expected api to be reachable from frontend, prometheus and resolver, got %v", from)
+	}
+}
+
+func TestNetworkPortRanges(t *testing.T) {
+	content := `apiVersion: v1
+kind: Pod
+metadata:
+  name: client
+  labels:
+    app: client
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: server
+  labels:
+    app: server
+spec:
+  containers:
+    - name: server
+      ports:
+        - name: web
+          containerPort: 8080
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: client-egress
+spec:
+  podSelector:
+    matchLabels:
+      app: client
+  policyTypes: [Egress]
+  egress:
+    - ports:
+        - port: 8000
+          endPort: 9000
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: server-ingress
+spec:
+  podSelector:
+    matchLabels:
+      app: server
+  ingress:
+    - ports:
+        - port: web
+        - port: 8500
+          endPort: 9500
+        - port: 9600
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	network := k8s.BuildGraph(resources).Network()
+	client, server := network.Workloads[0], network.Workloads[1]
+
+	traffic, ok := network.Allowed(client, server)
+	if !ok {
+		t.Fatal("Expected the client to reach the server")
+	}
+	if got, want := strings.Join(traffic.Ports, ","), "TCP/8080,TCP/8500-9000"; got != want {
+		t.Errorf("Expected ports %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,103 @@
+package k8s
+
+import "fmt"
+
+// OwnerNode is a resource in the ownership tree with the objects it owns
+type OwnerNode struct {
+	Node  Node
+	Owned []*OwnerNode // In load order
+}
+
+// analyzeOwners records an edge to every owner in metadata.ownerReferences,
+// and an issue if a loaded owner has a different uid than the reference,
+// e.g. when the manifests were exported at different times
+func (g *Graph) analyzeOwners(r Resource, from Node) {
+	for i, ref := range r.Metadata.OwnerReferences {
+		if ref.Kind == "" || ref.Name == "" {
+			continue
+		}
+		// Owners are in the same namespace, or cluster-scoped
+		group, _ := splitAPIVersion(ref.APIVersion)
+		to := Node{Group: group, Kind: ref.Kind, Namespace: from.Namespace, Name: ref.Name}
+		field := fmt.Sprintf("metadata.ownerReferences[%d]", i)
+		e := g.addEdge(Edge{From: from, To: to, Type: EdgeOwnedBy, Field: field})
+		if e.Dangling || ref.UID == "" {
+			continue
+		}
+		if owner := g.index.byKey[e.To.key()]; owner.Metadata.UID != "" && owner.Metadata.UID != ref.UID {
+			g.addIssue(Issue{Node: from, Field: field + ".uid", Severity: Warning,
+				Message: fmt.Sprintf("%s has uid %s, not %s", e.To, owner.Metadata.UID, ref.UID)})
+		}
+	}
+}
+
+// Owner returns the loaded owner of n: its controller if the controller is
+// loaded, otherwise the first loaded owner
+func (g *Graph) Owner(n Node) (Node, bool) {
+	res, ok := g.Resource(n)
+	if !ok {
+		return Node{}, false
+	}
+	n = g.lookup(n)
+	var owner Node
+	found := false
+	for _, ref := range res.Metadata.OwnerReferences {
+		group, _ := splitAPIVersion(ref.APIVersion)
+		resolved, ok := g.index.resolve(g.opts.normalize(Node{Group: group, Kind: ref.Kind, Namespace: n.Namespace, Name: ref.Name}))
+		switch {
+		case !ok:
+			continue
+		case ref.Controller:
+			return resolved, true
+		case !found:
+			owner, found = resolved, true
+		}
+	}
+	return owner, found
+}
+
+// OwnerTree nests the loaded resources beneath their owners, see Owner.
+// The roots are the resources without a loaded owner, in load order.
+func (g *Graph) OwnerTree() []*OwnerNode {
+	tree := make(map[key]*OwnerNode, len(g.nodes))
+	for _, n := range g.nodes {
+		tree[n.key()] = &OwnerNode{Node: n}
+	}
+	owners := make(map[key]key)
+	for _, n := range g.nodes {
+		if owner, ok := g.Owner(n); ok && owner.key() != n.key() {
+			owners[n.key()] = owner.key()
+			tree[owner.key()].Owned = append(tree[owner.key()].Owned, tree[n.key()])
+		}
+	}
+
+	// Resources owning each other in a cycle have no root; the first of
+	// each cycle becomes one and the reference back to it is dropped
+	var roots []*OwnerNode
+	placed := make(map[key]bool)
+	var place func(t *OwnerNode)
+	place = func(t *OwnerNode) {
+		placed[t.Node.key()] = true
+		owned := t.Owned[:0]
+		for _, c := range t.Owned {
+			if !placed[c.Node.key()] {
+				owned = append(owned, c)
+				place(c)
+			}
+		}
+		t.Owned = owned
+	}
+	for _, n := range g.nodes {
+		if _, owned := owners[n.key()]; !owned {
+			roots = append(roots, tree[n.key()])
+			place(tree[n.key()])
+		}
+	}
+	for _, n := range g.nodes {
+		if !placed[n.key()] {
+			roots = append(roots, tree[n.key()])
+			place(tree[n.key()])
+		}
+	}
+	return roots
+}
@@ -0,0 +1,150 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestOwnerReferences(t *testing.T) {
+	content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  uid: 1111
+---
+apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: web-5d8f
+  uid: 2222
+  ownerReferences:
+    - apiVersion: apps/v1
+      kind: Deployment
+      name: web
+      uid: 1111
+      controller: true
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-5d8f-abcde
+  ownerReferences:
+    - apiVersion: apps/v1
+      kind: ReplicaSet
+      name: web-5d8f
+      uid: 9999
+      controller: true
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: backup-28000
+  ownerReferences:
+    - apiVersion: batch/v1
+      kind: CronJob
+      name: backup
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: orphan
+  ownerReferences:
+    - apiVersion: apps/v1
+      kind: ReplicaSet
+      name: gone
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := resources[1].Metadata.OwnerReferences; len(got) != 1 || got[0].UID != "1111" || !got[0].Controller {
+		t.Errorf("Expected a controller ownerReference with uid 1111, got %+v", got)
+	}
+	if resources[1].Metadata.UID != "2222" {
+		t.Errorf("Expected uid 2222, got %q", resources[1].Metadata.UID)
+	}
+
+	g := k8s.BuildGraph(resources)
+	nodes := g.Nodes()
+
+	var edges []string
+	for _, e := range g.Edges() {
+		if e.Type == k8s.EdgeOwnedBy {
+			edges = append(edges, e.From.String()+" → "+e.To.String()+" "+e.Field)
+		}
+	}
+	want := []string{
+		"ReplicaSet/web-5d8f → Deployment/web metadata.ownerReferences[0]",
+		"Pod/web-5d8f-abcde → ReplicaSet/web-5d8f metadata.ownerReferences[0]",
+		"Job/backup-28000 → CronJob/backup metadata.ownerReferences[0]",
+		"Pod/orphan → ReplicaSet/gone metadata.ownerReferences[0]",
+	}
+	if strings.Join(edges, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected owner edges:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(edges, "\n"))
+	}
+	if dangling := g.Dangling(); len(dangling) != 1 || dangling[0].To.Name != "gone" {
+		t.Errorf("Expected only the orphan's owner to be dangling, got %v", dangling)
+	}
+
+	var issues []string
+	for _, issue := range g.IssuesFor(nodes[2]) {
+		issues = append(issues, issue.String())
+	}
+	wantIssue := "Pod/web-5d8f-abcde: metadata.ownerReferences[0].uid: ReplicaSet/web-5d8f has uid 2222, not 9999"
+	if len(issues) != 1 || issues[0] != wantIssue {
+		t.Errorf("Expected issue %q, got %v", wantIssue, issues)
+	}
+
+	var tree []string
+	var walk func(roots []*k8s.OwnerNode, indent string)
+	walk = func(roots []*k8s.OwnerNode, indent string) {
+		for _, n := range roots {
+			tree = append(tree, indent+n.Node.String())
+			walk(n.Owned, indent+"  ")
+		}
+	}
+	walk(g.OwnerTree(), "")
+	wantTree := []string{
+		"Deployment/web",
+		"  ReplicaSet/web-5d8f",
+		"    Pod/web-5d8f-abcde",
+		"CronJob/backup",
+		"  Job/backup-28000",
+		"Pod/orphan",
+	}
+	if strings.Join(tree, "\n") != strings.Join(wantTree, "\n") {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", strings.Join(wantTree, "\n"), strings.Join(tree, "\n"))
+	}
+}
+
+func TestOwnerTreeCycle(t *testing.T) {
+	content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  ownerReferences:
+    - {apiVersion: v1, kind: ConfigMap, name: b}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+  ownerReferences:
+    - {apiVersion: v1, kind: ConfigMap, name: a}
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	roots := k8s.BuildGraph(resources).OwnerTree()
+	if len(roots) != 1 || roots[0].Node.Name != "a" || len(roots[0].Owned) != 1 || len(roots[0].Owned[0].Owned) != 0 {
+		t.Errorf("Expected the cycle to be broken at ConfigMap/a, got %+v", roots)
+	}
+}
@@ -0,0 +1,158 @@
+package k8s
+
+// PodRef is a reference from a pod spec to another resource
+type PodRef struct {
+	Kind      string   // ConfigMap, Secret, ServiceAccount or PersistentVolumeClaim
+	Name      string   // Name of the referenced resource
+	Type      EdgeType // EdgeMounts for volumes, EdgeReferences otherwise
+	Field     string   // Path of the field holding the name
+	Container string   // Container using the reference, empty for pod-level references and unmounted volumes
+	Key       string   // Key of the ConfigMap or Secret, if a single key is used
+	Optional  bool     // Whether the pod starts without the resource or key
+}
+
+// containerLists are the pod spec fields holding containers
+var containerLists = []string{"initContainers", "containers", "ephemeralContainers"}
+
+// PodSpecRefs returns every reference made by the pod spec of a workload or
+// Pod, in the order the fields appear in the spec. It returns nil for kinds
+// without a pod template.
+func PodSpecRefs(r Resource) []PodRef {
+	tmpl, ok := podTemplate(r)
+	if !ok {
+		return nil
+	}
+	return podSpecRefs(tmpl.get("spec"))
+}
+
+// podSpecRefs walks a pod spec for references to other resources
+func podSpecRefs(spec field) []PodRef {
+	var refs []PodRef
+	add := func(ref PodRef, name field) {
+		if ref.Name = name.str(); ref.Name == "" {
+			return
+		}
+		if ref.Type == "" {
+			ref.Type = EdgeReferences
+		}
+		ref.Field = name.path
+		refs = append(refs, ref)
+	}
+
+	// Service account and image pull secrets
+	if name := spec.get("serviceAccountName"); name.str() != "" {
+		add(PodRef{Kind: "ServiceAccount"}, name)
+	} else {
+		add(PodRef{Kind: "ServiceAccount"}, spec.get("serviceAccount"))
+	}
+	for _, s := range spec.get("imagePullSecrets").items() {
+		add(PodRef{Kind: "Secret"}, s.get("name"))
+	}
+
+	// Volumes, once for every container mounting them
+	mounts := volumeMounts(spec)
+	for _, vol := range spec.get("volumes").items() {
+		var volRefs []PodRef
+		if src := vol.get("configMap"); src.value != nil {
+			volRefs = append(volRefs, projectedRefs(src, "ConfigMap", "name")...)
+		}
+		if src := vol.get("secret"); src.value != nil {
+			volRefs = append(volRefs, projectedRefs(src, "Secret", "secretName")...)
+		}
+		for _, source := range vol.get("projected").get("sources").items() {
+			if src := source.get("configMap"); src.value != nil {
+				volRefs = append(volRefs, projectedRefs(src, "ConfigMap", "name")...)
+			}
+			if src := source.get("secret"); src.value != nil {
+				volRefs = append(volRefs, projectedRefs(src, "Secret", "name")...)
+			}
+		}
+		if name := vol.get("persistentVolumeClaim").get("claimName"); name.str() != "" {
+			volRefs = append(volRefs, PodRef{Kind: "PersistentVolumeClaim", Name: name.str(), Type: EdgeMounts, Field: name.path})
+		}
+		if name := vol.get("csi").get("nodePublishSecretRef").get("name"); name.str() != "" {
+			volRefs = append(volRefs, PodRef{Kind: "Secret", Name: name.str(), Type: EdgeReferences, Field: name.path})
+		}
+
+		containers := mounts[vol.get("name").str()]
+		for _, ref := range volRefs {
+			if len(containers) == 0 {
+				refs = append(refs, ref)
+			}
+			for _, c := range containers {
+				ref.Container = c
+				refs = append(refs, ref)
+			}
+		}
+	}
+
+	// Container environment
+	for _, list := range containerLists {
+		for _, c := range spec.get(list).items() {
+			container := c.get("name").str()
+			for _, env := range c.get("envFrom").items() {
+				for _, src := range []source{{"ConfigMap", env.get("configMapRef")}, {"Secret", env.get("secretRef")}} {
+					add(PodRef{Kind: src.kind, Container: container, Optional: optional(src.field)}, src.get("name"))
+				}
+			}
+			for _, env := range c.get("env").items() {
+				from := env.get("valueFrom")
+				for _, src := range []source{{"ConfigMap", from.get("configMapKeyRef")}, {"Secret", from.get("secretKeyRef")}} {
+					ref := PodRef{Kind: src.kind, Container: container, Key: src.get("key").str(), Optional: optional(src.field)}
+					add(ref, src.get("name"))
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// volumeMounts returns the containers mounting each volume of a pod spec,
+// in the order they are declared
+func volumeMounts(spec field) map[string][]string {
+	mounts := make(map[string][]string)
+	for _, list := range containerLists {
+		for _, c := range spec.get(list).items() {
+			container := c.get("name").str()
+			for _, m := range c.get("volumeMounts").items() {
+				name := m.get("name").str()
+				if n := len(mounts[name]); n == 0 || mounts[name][n-1] != container {
+					mounts[name] = append(mounts[name], container)
+				}
+			}
+		}
+	}
+	return mounts
+}
+
+// source is an env or envFrom source field and the kind it names
+type source struct {
+	kind string
+	field
+}
+
+// optional reports whether a source is marked optional
+func optional(src field) bool {
+	b, _ := src.get("optional").value.(bool)
+	return b
+}
+
+// projectedRefs returns the references of a configMap or secret volume
+// source: one per projected key, or a single one if all keys are used
+func projectedRefs(src field, kind, nameKey string) []PodRef {
+	name := src.get(nameKey)
+	if name.str() == "" {
+		return nil
+	}
+	ref := PodRef{Kind: kind, Name: name.str(), Type: EdgeMounts, Field: name.path, Optional: optional(src)}
+	items := src.get("items").items()
+	if len(items) == 0 {
+		return []PodRef{ref}
+	}
+	refs := make([]PodRef, 0, len(items))
+	for _, item := range items {
+		ref.Key = item.get("key").str()
+		refs = append(refs, ref)
+	}
+	return refs
+}
@@ -0,0 +1,212 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestPodSpecRefs(t *testing.T) {
+	content := `apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  serviceAccountName: app
+  imagePullSecrets:
+    - name: registry
+  volumes:
+    - name: config
+      configMap:
+        name: app-config
+        items:
+          - key: app.yaml
+            path: app.yaml
+    - name: bundle
+      projected:
+        sources:
+          - secret:
+              name: tls
+          - configMap:
+              name: ca
+              optional: true
+          - serviceAccountToken:
+              path: token
+    - name: data
+      persistentVolumeClaim:
+        claimName: data
+    - name: vault
+      csi:
+        driver: secrets-store.csi.k8s.io
+        nodePublishSecretRef:
+          name: vault-creds
+  initContainers:
+    - name: migrate
+      volumeMounts:
+        - name: config
+          mountPath: /etc/app
+      envFrom:
+        - secretRef:
+            name: db
+  containers:
+    - name: app
+      volumeMounts:
+        - name: config
+          mountPath: /etc/app
+        - name: bundle
+          mountPath: /etc/bundle
+        - name: data
+          mountPath: /data
+      envFrom:
+        - configMapRef:
+            name: app-env
+            optional: true
+      env:
+        - name: LEVEL
+          valueFrom:
+            configMapKeyRef:
+              name: app-config
+              key: level
+        - name: TOKEN
+          valueFrom:
+            secretKeyRef:
+              name: api
+              key: token
+  ephemeralContainers:
+    - name: debug
+      envFrom:
+        - secretRef:
+            name: debug
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want := []k8s.PodRef{
+		{Kind: "ServiceAccount", Name: "app", Type: k8s.EdgeReferences, Field: "spec.serviceAccountName"},
+		{Kind: "Secret", Name: "registry", Type: k8s.EdgeReferences, Field: "spec.imagePullSecrets[0].name"},
+		{Kind: "ConfigMap", Name: "app-config", Type: k8s.EdgeMounts, Field: "spec.volumes[0].configMap.name", Container: "migrate", Key: "app.yaml"},
+		{Kind: "ConfigMap", Name: "app-config", Type: k8s.EdgeMounts, Field: "spec.volumes[0].configMap.name", Container: "app", Key: "app.yaml"},
+		{Kind: "Secret", Name: "tls", Type: k8s.EdgeMounts, Field: "spec.volumes[1].projected.sources[0].secret.name", Container: "app"},
+		{Kind: "ConfigMap", Name: "ca", Type: k8s.EdgeMounts, Field: "spec.volumes[1].projected.sources[1].configMap.name", Container: "app", Optional: true},
+		{Kind: "PersistentVolumeClaim", Name: "data", Type: k8s.EdgeMounts, Field: "spec.volumes[2].persistentVolumeClaim.claimName", Container: "app"},
+		{Kind: "Secret", Name: "vault-creds", Type: k8s.EdgeReferences, Field: "spec.volumes[3].csi.nodePublishSecretRef.name"}, // Not mounted
+		{Kind: "Secret", Name: "db", Type: k8s.EdgeReferences, Field: "spec.initContainers[0].envFrom[0].secretRef.name", Container: "migrate"},
+		{Kind: "ConfigMap", Name: "app-env", Type: k8s.EdgeReferences, Field: "spec.containers[0].envFrom[0].configMapRef.name", Container: "app", Optional: true},
+		{Kind: "ConfigMap", Name: "app-config", Type: k8s.EdgeReferences, Field: "spec.containers[0].env[0].valueFrom.configMapKeyRef.name", Container: "app", Key: "level"},
+		{Kind: "Secret", Name: "api", Type: k8s.EdgeReferences, Field: "spec.containers[0].env[1].valueFrom.secretKeyRef.name", Container: "app", Key: "token"},
+		{Kind: "Secret", Name: "debug", Type: k8s.EdgeReferences, Field: "spec.ephemeralContainers[0].envFrom[0].secretRef.name", Container: "debug"},
+	}
+	got := k8s.PodSpecRefs(resources[0])
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d refs, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Ref %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	g := k8s.BuildGraph(resources)
+	if edges := g.Out(g.Nodes()[0]); len(edges) != len(want) {
+		t.Errorf("Expected %d edges, got %d", len(want), len(edges))
+	}
+}
+
+func TestKeyReferences(t *testing.T) {
+	content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  LOG_LEVEL: debug
+binaryData:
+  logo.png: iVBORw0KGgo=
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: api
+stringData:
+  token: s3cr3t
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      volumes:
+        - name: assets
+          configMap:
+            name: app-config
+            items:
+              - key: logo.png
+                path: logo.png
+              - key: favicon.ico
+                path: favicon.ico
+      initContainers:
+        - name: copy-assets
+          volumeMounts:
+            - name: assets
+              mountPath: /assets
+      containers:
+        - name: app
+          volumeMounts:
+            - name: assets
+              mountPath: /assets
+          env:
+            - name: LOG_LEVEL
+              valueFrom:
+                configMapKeyRef:
+                  name: app-config
+                  key: LOG_LEVEL
+            - name: LOG_FORMAT
+              valueFrom:
+                configMapKeyRef:
+                  name: app-config
+                  key: LOG_FORMAT
+            - name: TRACING
+              valueFrom:
+                configMapKeyRef:
+                  name: app-config
+                  key: TRACING
+                  optional: true
+            - name: TOKEN
+              valueFrom:
+                secretKeyRef:
+                  name: api
+                  key: token
+            - name: PASSWORD
+              valueFrom:
+                secretKeyRef:
+                  name: api
+                  key: password
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+
+	want := []string{
+		`Deployment/app: spec.template.spec.volumes[0].configMap.name: key "favicon.ico" not found in ConfigMap/app-config`,
+		`Deployment/app: spec.template.spec.containers[0].env[1].valueFrom.configMapKeyRef.name: key "LOG_FORMAT" not found in ConfigMap/app-config`,
+		`Deployment/app: spec.template.spec.containers[0].env[4].valueFrom.secretKeyRef.name: key "password" not found in Secret/api`,
+	}
+	issues := g.IssuesFor(g.Nodes()[2])
+	if len(issues) != len(want) {
+		t.Fatalf("Expected %d issues, got %v", len(want), issues)
+	}
+	for i, issue := range issues {
+		if issue.String() != want[i] {
+			t.Errorf("Expected %s, got %s", want[i], issue)
+		}
+		if issue.Severity != k8s.Warning {
+			t.Errorf("Expected a warning, got %s", issue.Severity)
+		}
+	}
+}
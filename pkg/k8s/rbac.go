@@ -0,0 +1,253 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PolicyRule is a rule of a Role or ClusterRole granting verbs on resources
+type PolicyRule struct {
+	APIGroups       []string
+	Resources       []string
+	ResourceNames   []string
+	NonResourceURLs []string
+	Verbs           []string
+}
+
+// Subject is a user, group or ServiceAccount named by a binding
+type Subject struct {
+	Kind      string
+	APIGroup  string
+	Name      string
+	Namespace string
+}
+
+// RoleRef is the Role or ClusterRole granted by a binding
+type RoleRef struct {
+	APIGroup string
+	Kind     string
+	Name     string
+}
+
+// Permission is a set of verbs a subject may use on one resource
+type Permission struct {
+	Namespace     string   // Namespace the permission applies in, empty if cluster-wide
+	Resource      string   // Resource and API group, e.g. deployments.apps, or a non-resource URL
+	ResourceNames []string // Names the permission is restricted to, if any
+	Verbs         []string
+	Via           []string // Bindings and roles granting it, e.g. "RoleBinding/x → Role/y"
+}
+
+// policyRules returns the rules of a Role or ClusterRole. Other kinds may
+// have a top-level rules field of any shape, so they have none.
+func policyRules(r Resource) []PolicyRule {
+	if r.Kind != "Role" && r.Kind != "ClusterRole" {
+		return nil
+	}
+	var rules []PolicyRule
+	for _, rule := range r.root().get("rules").items() {
+		rules = append(rules, PolicyRule{
+			APIGroups:       rule.get("apiGroups").strings(),
+			Resources:       rule.get("resources").strings(),
+			ResourceNames:   rule.get("resourceNames").strings(),
+			NonResourceURLs: rule.get("nonResourceURLs").strings(),
+			Verbs:           rule.get("verbs").strings(),
+		})
+	}
+	return rules
+}
+
+// isBinding reports whether r is a RoleBinding or ClusterRoleBinding
+func isBinding(r Resource) bool {
+	return r.Kind == "RoleBinding" || r.Kind == "ClusterRoleBinding"
+}
+
+// subjects returns the subjects of a RoleBinding or ClusterRoleBinding
+func subjects(r Resource) []Subject {
+	if !isBinding(r) {
+		return nil
+	}
+	var subs []Subject
+	for _, sub := range r.root().get("subjects").items() {
+		subs = append(subs, Subject{
+			Kind:      sub.get("kind").str(),
+			APIGroup:  sub.get("apiGroup").str(),
+			Name:      sub.get("name").str(),
+			Namespace: sub.get("namespace").str(),
+		})
+	}
+	return subs
+}
+
+// roleRef returns the role granted by a RoleBinding or ClusterRoleBinding
+func roleRef(r Resource) (RoleRef, bool) {
+	if !isBinding(r) {
+		return RoleRef{}, false
+	}
+	ref := r.root().get("roleRef")
+	role := RoleRef{APIGroup: ref.get("apiGroup").str(), Kind: ref.get("kind").str(), Name: ref.get("name").str()}
+	return role, role.Name != ""
+}
+
+// analyzeRBAC records the edges of bindings and aggregated ClusterRoles
+func (g *Graph) analyzeRBAC(r Resource, from Node) {
+	switch r.Kind {
+	case "RoleBinding", "ClusterRoleBinding":
+		if ref, ok := roleRef(r); ok {
+			to := Node{Group: ref.APIGroup, Kind: ref.Kind, Namespace: from.Namespace, Name: ref.Name}
+			g.addEdge(Edge{From: from, To: to, Type: EdgeBinds, Field: "roleRef"})
+		}
+		for i, sub := range subjects(r) {
+			if sub.Kind != "ServiceAccount" || sub.Name == "" {
+				continue
+			}
+			to := Node{Kind: "ServiceAccount", Namespace: subjectNamespace(sub, from), Name: sub.Name}
+			g.addEdge(Edge{From: from, To: to, Type: EdgeBinds, Field: fmt.Sprintf("subjects[%d]", i)})
+		}
+
+	case "ClusterRole":
+		for _, selector := range r.root().get("aggregationRule").get("clusterRoleSelectors").items() {
+			sel := g.labelSelector(from, selector)
+			if sel == nil {
+				continue
+			}
+			for _, role := range g.index.byKind["ClusterRole"] {
+				if role.Metadata.Name != from.Name && sel.Matches(role.Metadata.Labels) {
+					g.addEdge(Edge{From: from, To: g.Node(*role), Type: EdgeAggregates, Field: selector.path})
+				}
+			}
+		}
+	}
+}
+
+// subjectNamespace returns the namespace of a ServiceAccount subject, which
+// defaults to the namespace of a RoleBinding
+func subjectNamespace(sub Subject, binding Node) string {
+	if sub.Namespace != "" {
+		return sub.Namespace
+	}
+	return binding.Namespace
+}
+
+// Permissions returns the effective permissions a ServiceAccount is granted
+// by the loaded RoleBindings and ClusterRoleBindings, including those bound
+// to the system:serviceaccounts groups. Rules of aggregated ClusterRoles are
+// included. Permissions are merged per namespace, resource and resource
+// names, and sorted by namespace and resource.
+func (g *Graph) Permissions(sa Node) []Permission {
+	sa = g.opts.normalize(sa)
+	merged := make(map[string]*Permission)
+	var keys []string
+	add := func(ns, resource string, names []string, verbs []string, via string) {
+		k := ns + "\x00" + resource + "\x00" + strings.Join(names, ",")
+		p, ok := merged[k]
+		if !ok {
+			p = &Permission{Namespace: ns, Resource: resource, ResourceNames: names}
+			merged[k] = p
+			keys = append(keys, k)
+		}
+		p.Verbs = union(p.Verbs, verbs)
+		p.Via = union(p.Via, []string{via})
+	}
+
+	for _, kind := range []string{"RoleBinding", "ClusterRoleBinding"} {
+		for _, b := range g.index.byKind[kind] {
+			binding := g.Node(*b)
+			ref, ok := roleRef(*b)
+			if !ok || !bindsServiceAccount(*b, binding, sa) {
+				continue
+			}
+			role, ok := g.index.resolve(g.opts.normalize(Node{Group: ref.APIGroup, Kind: ref.Kind, Namespace: binding.Namespace, Name: ref.Name}))
+			if !ok {
+				continue
+			}
+			ns := binding.Namespace
+			via := binding.String() + " → " + role.String()
+			for _, rule := range g.rules(role, make(map[Node]bool)) {
+				for _, url := range rule.NonResourceURLs {
+					// Non-resource URLs can only be granted cluster-wide
+					if kind == "ClusterRoleBinding" {
+						add("", url, nil, rule.Verbs, via)
+					}
+				}
+				groups := rule.APIGroups
+				if len(groups) == 0 && len(rule.Resources) > 0 {
+					groups = []string{""}
+				}
+				for _, group := range groups {
+					for _, resource := range rule.Resources {
+						if group != "" {
+							resource += "." + group
+						}
+						add(ns, resource, rule.ResourceNames, rule.Verbs, via)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	perms := make([]Permission, len(keys))
+	for i, k := range keys {
+		perms[i] = *merged[k]
+	}
+	return perms
+}
+
+// rules returns the rules of a role together with those of the ClusterRoles
+// it aggregates
+func (g *Graph) rules(role Node, visited map[Node]bool) []PolicyRule {
+	if visited[role] {
+		return nil
+	}
+	visited[role] = true
+	res, ok := g.index.byKey[role.key()]
+	if !ok {
+		return nil
+	}
+	rules := policyRules(*res)
+	for _, e := range g.Out(role) {
+		if e.Type == EdgeAggregates && !e.Dangling {
+			rules = append(rules[:len(rules):len(rules)], g.rules(e.To, visited)...)
+		}
+	}
+	return rules
+}
+
+// bindsServiceAccount reports whether a binding names sa directly or through
+// one of the groups every ServiceAccount belongs to
+func bindsServiceAccount(b Resource, binding, sa Node) bool {
+	for _, sub := range subjects(b) {
+		switch sub.Kind {
+		case "ServiceAccount":
+			if sub.Name == sa.Name && subjectNamespace(sub, binding) == sa.Namespace {
+				return true
+			}
+		case "Group":
+			switch sub.Name {
+			case "system:serviceaccounts", "system:serviceaccounts:" + sa.Namespace, "system:authenticated":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// union returns the sorted union of two string sets. A "*" absorbs every
+// other value.
+func union(a, b []string) []string {
+	seen := make(map[string]bool)
+	for _, s := range append(a[:len(a):len(a)], b...) {
+		seen[s] = true
+	}
+	if seen["*"] {
+		return []string{"*"}
+	}
+	out := make([]string, 0, len(seen))
+	for s := range seen {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
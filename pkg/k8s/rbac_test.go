@@ -0,0 +1,169 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestRBAC(t *testing.T) {
+	content := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: app
+  namespace: shop
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: shop
+spec:
+  template:
+    spec:
+      serviceAccountName: app
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: pod-reader
+  namespace: shop
+rules:
+  - apiGroups: [""]
+    resources: [pods, pods/log]
+    verbs: [get, list]
+  - apiGroups: [""]
+    resources: [pods]
+    verbs: [watch]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: app-pod-reader
+  namespace: shop
+subjects:
+  - kind: ServiceAccount
+    name: app
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: pod-reader
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: monitoring
+aggregationRule:
+  clusterRoleSelectors:
+    - matchLabels:
+        rbac.example.com/aggregate-to-monitoring: "true"
+rules: []
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: monitoring-deployments
+  labels:
+    rbac.example.com/aggregate-to-monitoring: "true"
+rules:
+  - apiGroups: [apps]
+    resources: [deployments]
+    verbs: [get, list, watch]
+  - nonResourceURLs: [/metrics]
+    verbs: [get]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: all-monitoring
+subjects:
+  - kind: Group
+    apiGroup: rbac.authorization.k8s.io
+    name: system:serviceaccounts:shop
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: monitoring
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: other-tenant
+  namespace: other
+subjects:
+  - kind: ServiceAccount
+    name: app
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: monitoring-deployments
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+	nodes := g.Nodes()
+	relations := []struct {
+		node k8s.Node
+		want []string
+	}{
+		{nodes[0], []string{"← Used by Deployment/app", "← Bound by RoleBinding/app-pod-reader"}},
+		{nodes[3], []string{"→ Binds Role/pod-reader", "→ Binds ServiceAccount/app"}},
+		{nodes[4], []string{"→ Aggregates ClusterRole/monitoring-deployments", "← Bound by ClusterRoleBinding/all-monitoring"}},
+	}
+	for _, tt := range relations {
+		if got := g.Relations(tt.node); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: expected %v, got %v", tt.node, tt.want, got)
+		}
+	}
+
+	var got []string
+	for _, p := range g.Permissions(nodes[0]) {
+		got = append(got, p.Namespace+" "+p.Resource+" "+strings.Join(p.Verbs, ","))
+	}
+	want := []string{
+		" /metrics get",
+		" deployments.apps get,list,watch",
+		"shop pods get,list,watch",
+		"shop pods/log get,list",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected permissions:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestRBACFieldsOfOtherKinds(t *testing.T) {
+	content := `apiVersion: example.com/v1
+kind: Firewall
+metadata:
+  name: edge
+rules:
+  allow: all
+subjects: everyone
+roleRef: admin
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: app
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %d", len(resources))
+	}
+	if rules, _ := resources[0].Get("rules", "allow"); rules != "all" {
+		t.Errorf("Expected rules.allow to be preserved, got %v", rules)
+	}
+	g := k8s.BuildGraph(resources)
+	if edges := g.Out(g.Nodes()[0]); len(edges) != 0 {
+		t.Errorf("Expected no edges from a Firewall, got %v", edges)
+	}
+	if perms := g.Permissions(g.Nodes()[1]); len(perms) != 0 {
+		t.Errorf("Expected no permissions, got %v", perms)
+	}
+}
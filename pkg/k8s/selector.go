@@ -0,0 +1,157 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Label selector operators
+const (
+	OpIn           = "In"
+	OpNotIn        = "NotIn"
+	OpExists       = "Exists"
+	OpDoesNotExist = "DoesNotExist"
+)
+
+// LabelSelector is a Kubernetes label selector. The requirements of
+// MatchLabels and MatchExpressions are ANDed together.
+type LabelSelector struct {
+	MatchLabels      map[string]string
+	MatchExpressions []Requirement
+}
+
+// Requirement is a single matchExpressions entry
+type Requirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// SelectorFromMap returns a selector requiring every label in m, as used by
+// Service and ReplicationController selectors
+func SelectorFromMap(m map[string]string) *LabelSelector {
+	return &LabelSelector{MatchLabels: m}
+}
+
+// ParseLabelSelector parses a decoded LabelSelector object with matchLabels
+// and matchExpressions. A nil value yields a nil selector, which matches
+// nothing.
+func ParseLabelSelector(v interface{}) (*LabelSelector, error) {
+	if v == nil {
+		return nil, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("label selector must be a map")
+	}
+	s := &LabelSelector{}
+	if ml, ok := m["matchLabels"].(map[string]interface{}); ok {
+		s.MatchLabels = convertToStringMap(ml)
+	}
+	exprs, _ := m["matchExpressions"].([]interface{})
+	for i, e := range exprs {
+		expr, _ := e.(map[string]interface{})
+		req := Requirement{}
+		req.Key, _ = expr["key"].(string)
+		req.Operator, _ = expr["operator"].(string)
+		values, _ := expr["values"].([]interface{})
+		for _, v := range values {
+			req.Values = append(req.Values, fmt.Sprint(v))
+		}
+		if req.Key == "" {
+			return nil, fmt.Errorf("matchExpressions[%d]: key is required", i)
+		}
+		switch req.Operator {
+		case OpIn, OpNotIn:
+			if len(req.Values) == 0 {
+				return nil, fmt.Errorf("matchExpressions[%d]: operator %s requires values", i, req.Operator)
+			}
+		case OpExists, OpDoesNotExist:
+			if len(req.Values) > 0 {
+				return nil, fmt.Errorf("matchExpressions[%d]: operator %s must not have values", i, req.Operator)
+			}
+		default:
+			return nil, fmt.Errorf("matchExpressions[%d]: unknown operator %q", i, req.Operator)
+		}
+		s.MatchExpressions = append(s.MatchExpressions, req)
+	}
+	return s, nil
+}
+
+// Empty reports whether the selector has no requirements. An empty selector
+// matches every set of labels, while a nil selector matches none.
+func (s *LabelSelector) Empty() bool {
+	return s != nil && len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0
+}
+
+// Matches reports whether labels satisfy every requirement of the selector
+func (s *LabelSelector) Matches(labels map[string]string) bool {
+	if s == nil {
+		return false
+	}
+	if !matchLabels(s.MatchLabels, labels) {
+		return false
+	}
+	for _, req := range s.MatchExpressions {
+		if !req.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether labels satisfy the requirement
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, exists := labels[r.Key]
+	switch r.Operator {
+	case OpIn:
+		return exists && contains(r.Values, value)
+	case OpNotIn:
+		return !exists || !contains(r.Values, value)
+	case OpExists:
+		return exists
+	case OpDoesNotExist:
+		return !exists
+	}
+	return false
+}
+
+// String formats the selector like kubectl, e.g. "app=web,tier in (a,b)"
+func (s *LabelSelector) String() string {
+	if s == nil {
+		return "<none>"
+	}
+	var parts []string
+	keys := make([]string, 0, len(s.MatchLabels))
+	for k := range s.MatchLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, k+"="+s.MatchLabels[k])
+	}
+	for _, req := range s.MatchExpressions {
+		switch req.Operator {
+		case OpIn, OpNotIn:
+			parts = append(parts, fmt.Sprintf("%s %s (%s)", req.Key, strings.ToLower(req.Operator), strings.Join(req.Values, ",")))
+		case OpExists:
+			parts = append(parts, req.Key)
+		case OpDoesNotExist:
+			parts = append(parts, "!"+req.Key)
+		}
+	}
+	if len(parts) == 0 {
+		return "<all>"
+	}
+	return strings.Join(parts, ",")
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
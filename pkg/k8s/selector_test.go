@@ -0,0 +1,151 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"k8spreview/pkg/k8s"
+)
+
+func parseSelector(t *testing.T, src string) *k8s.LabelSelector {
+	t.Helper()
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	sel, err := k8s.ParseLabelSelector(v)
+	if err != nil {
+		t.Fatalf("ParseLabelSelector failed: %v", err)
+	}
+	return sel
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"app": "web", "tier": "frontend"}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{`{matchLabels: {app: web}}`, true},
+		{`{matchLabels: {app: api}}`, false},
+		{`{matchExpressions: [{key: tier, operator: In, values: [frontend, backend]}]}`, true},
+		{`{matchExpressions: [{key: tier, operator: In, values: [backend]}]}`, false},
+		{`{matchExpressions: [{key: tier, operator: NotIn, values: [backend]}]}`, true},
+		{`{matchExpressions: [{key: canary, operator: NotIn, values: ["true"]}]}`, true},
+		{`{matchExpressions: [{key: app, operator: Exists}]}`, true},
+		{`{matchExpressions: [{key: canary, operator: Exists}]}`, false},
+		{`{matchExpressions: [{key: canary, operator: DoesNotExist}]}`, true},
+		{`{matchLabels: {app: web}, matchExpressions: [{key: tier, operator: DoesNotExist}]}`, false},
+		{`{}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			if got := parseSelector(t, tt.selector).Matches(labels); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	var none *k8s.LabelSelector
+	if none.Matches(labels) {
+		t.Error("Expected a nil selector to match nothing")
+	}
+}
+
+func TestParseLabelSelectorErrors(t *testing.T) {
+	for _, src := range []string{
+		`{matchExpressions: [{key: app, operator: Equals, values: [web]}]}`,
+		`{matchExpressions: [{key: app, operator: In}]}`,
+		`{matchExpressions: [{key: app, operator: Exists, values: [web]}]}`,
+		`{matchExpressions: [{operator: Exists}]}`,
+	} {
+		var v interface{}
+		if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := k8s.ParseLabelSelector(v); err == nil {
+			t.Errorf("Expected an error for %s", src)
+		}
+	}
+}
+
+func TestSelectorIssuesAndEdges(t *testing.T) {
+	content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchExpressions:
+      - {key: app, operator: In, values: [web]}
+  template:
+    metadata:
+      labels:
+        app: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: broken
+spec:
+  selector:
+    matchLabels:
+      app: other
+  template:
+    metadata:
+      labels:
+        app: broken
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-abc
+  labels:
+    app: web
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: deny-all
+spec:
+  podSelector: {}
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+spec:
+  selector:
+    matchExpressions:
+      - {key: app, operator: NotIn, values: [broken]}
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+	nodes := g.Nodes()
+
+	relations := func(n k8s.Node) string {
+		return strings.Join(g.Relations(n), "\n")
+	}
+	if got := relations(nodes[0]); !strings.Contains(got, "→ Selects Pod/web-abc") || strings.Contains(got, "→ Selects Deployment") {
+		t.Errorf("Expected Deployment/web to select only its pod, got:\n%s", got)
+	}
+	if got := relations(nodes[3]); strings.Count(got, "→ Selects") != 3 {
+		t.Errorf("Expected empty podSelector to select all pods and workloads, got:\n%s", got)
+	}
+	if got := relations(nodes[4]); !strings.Contains(got, "Deployment/web") || strings.Contains(got, "Deployment/broken") {
+		t.Errorf("Expected PDB to honour NotIn, got:\n%s", got)
+	}
+
+	issues := g.Issues()
+	if len(issues) != 1 || issues[0].Node.Name != "broken" || issues[0].Severity != k8s.Error {
+		t.Fatalf("Expected one selector mismatch on Deployment/broken, got %v", issues)
+	}
+	if issues[0].Field != "spec.selector" {
+		t.Errorf("Expected issue on spec.selector, got %s", issues[0].Field)
+	}
+}
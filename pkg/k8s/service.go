@@ -0,0 +1,151 @@
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ServicePort maps a port of a Service to the container ports serving it
+type ServicePort struct {
+	Name       string // Name of the Service port, if any
+	Protocol   string // TCP, UDP or SCTP
+	Port       int
+	TargetPort string        // Container port number or name, defaults to Port
+	Field      string        // Path of the port, e.g. spec.ports[0]
+	Backends   []PortBackend // One per selected workload or pod, in load order
+}
+
+// PortBackend is the container port a Service port targets on one of the
+// workloads or pods the Service selects
+type PortBackend struct {
+	Workload  Node
+	Container string // Container exposing the port, empty if none does
+	Name      string // Name of the container port, if any
+	Port      int    // Number of the container port, 0 if none matches
+}
+
+// Exposed reports whether a container of the workload exposes the port
+func (b PortBackend) Exposed() bool {
+	return b.Container != ""
+}
+
+// containerPort is a port declared by a container of a pod template
+type containerPort struct {
+	container string
+	name      string
+	protocol  string
+	port      int
+}
+
+// containerPorts returns the ports declared by the containers of a
+// workload's pods, or of a Pod
+func containerPorts(r Resource) []containerPort {
+	tmpl, ok := podTemplate(r)
+	if !ok {
+		return nil
+	}
+	var ports []containerPort
+	for _, list := range containerLists {
+		for _, c := range tmpl.get("spec").get(list).items() {
+			for _, p := range c.get("ports").items() {
+				number, ok := p.get("containerPort").value.(int)
+				if !ok {
+					continue
+				}
+				protocol := p.get("protocol").str()
+				if protocol == "" {
+					protocol = "TCP"
+				}
+				ports = append(ports, containerPort{
+					container: c.get("name").str(),
+					name:      p.get("name").str(),
+					protocol:  protocol,
+					port:      number,
+				})
+			}
+		}
+	}
+	return ports
+}
+
+// ServicePorts returns the port map of a Service: each of its ports with the
+// container port the targetPort resolves to on every workload and pod the
+// Service selects. It returns nil for other kinds.
+func (g *Graph) ServicePorts(service Node) []ServicePort {
+	res, ok := g.Resource(service)
+	if !ok || res.Kind != "Service" {
+		return nil
+	}
+	var selected []*Resource
+	for _, e := range g.Out(service) {
+		if e.Type != EdgeSelects {
+			continue
+		}
+		if w, ok := g.index.byKey[e.To.key()]; ok {
+			selected = append(selected, w)
+		}
+	}
+
+	var ports []ServicePort
+	for _, p := range res.root().get("spec").get("ports").items() {
+		sp := ServicePort{Name: p.get("name").str(), Protocol: p.get("protocol").str(), Field: p.path}
+		if sp.Protocol == "" {
+			sp.Protocol = "TCP"
+		}
+		sp.Port, _ = p.get("port").value.(int)
+		switch v := p.get("targetPort").value.(type) {
+		case int:
+			sp.TargetPort = strconv.Itoa(v)
+		case string:
+			sp.TargetPort = v
+		default:
+			sp.TargetPort = strconv.Itoa(sp.Port)
+		}
+		for _, w := range selected {
+			sp.Backends = append(sp.Backends, targetPort(g.Node(*w), containerPorts(*w), sp))
+		}
+		ports = append(ports, sp)
+	}
+	return ports
+}
+
+// targetPort finds the container port a Service port targets on a workload.
+// A numeric targetPort matches containerPort, a named one matches the name.
+func targetPort(w Node, ports []containerPort, sp ServicePort) PortBackend {
+	number, err := strconv.Atoi(sp.TargetPort)
+	for _, p := range ports {
+		if p.protocol != sp.Protocol {
+			continue
+		}
+		if (err == nil && p.port == number) || (err != nil && p.name == sp.TargetPort) {
+			return PortBackend{Workload: w, Container: p.container, Name: p.name, Port: p.port}
+		}
+	}
+	return PortBackend{Workload: w}
+}
+
+// checkTargetPorts records an issue for every port of a Service whose
+// targetPort is exposed by none of the workloads and pods it selects. An
+// unresolved named port is an error, since the Service gets no endpoints
+// for it; a numeric one is a warning, since declaring container ports is
+// optional.
+func (g *Graph) checkTargetPorts(service Node) {
+	for _, sp := range g.ServicePorts(service) {
+		if len(sp.Backends) == 0 {
+			continue
+		}
+		exposed := false
+		for _, b := range sp.Backends {
+			exposed = exposed || b.Exposed()
+		}
+		if exposed {
+			continue
+		}
+		severity, target := Warning, sp.TargetPort
+		if _, err := strconv.Atoi(sp.TargetPort); err != nil {
+			severity, target = Error, strconv.Quote(sp.TargetPort)
+		}
+		g.addIssue(Issue{Node: service, Field: sp.Field, Severity: severity,
+			Message: fmt.Sprintf("targetPort %s/%s is not exposed by any selected pod", sp.Protocol, target)})
+	}
+}
@@ -0,0 +1,116 @@
+package k8s_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/k8s"
+)
+
+func TestServicePorts(t *testing.T) {
+	content := `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+  ports:
+    - name: http
+      port: 80
+      targetPort: http
+    - name: metrics
+      port: 9090
+    - name: admin
+      port: 8443
+      targetPort: admin
+    - name: dns
+      port: 53
+      protocol: UDP
+      targetPort: 53
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: app
+          ports:
+            - name: http
+              containerPort: 8080
+        - name: exporter
+          ports:
+            - containerPort: 9090
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+  labels:
+    app: web
+spec:
+  containers:
+    - name: shell
+      ports:
+        - name: http
+          containerPort: 8000
+        - containerPort: 53
+          protocol: TCP
+`
+	resources, err := k8s.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	g := k8s.BuildGraph(resources)
+	service := g.Nodes()[0]
+
+	var got []string
+	for _, sp := range g.ServicePorts(service) {
+		for _, b := range sp.Backends {
+			target := "not exposed"
+			if b.Exposed() {
+				target = fmt.Sprintf("%s/%d %q", b.Container, b.Port, b.Name)
+			}
+			got = append(got, fmt.Sprintf("%s/%d → %s → %s %s", sp.Protocol, sp.Port, sp.TargetPort, b.Workload, target))
+		}
+	}
+	want := []string{
+		`TCP/80 → http → Deployment/web app/8080 "http"`,
+		`TCP/80 → http → Pod/debug shell/8000 "http"`,
+		`TCP/9090 → 9090 → Deployment/web exporter/9090 ""`,
+		`TCP/9090 → 9090 → Pod/debug not exposed`,
+		`TCP/8443 → admin → Deployment/web not exposed`,
+		`TCP/8443 → admin → Pod/debug not exposed`,
+		`UDP/53 → 53 → Deployment/web not exposed`,
+		`UDP/53 → 53 → Pod/debug not exposed`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected port map:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	var issues []string
+	for _, issue := range g.IssuesFor(service) {
+		issues = append(issues, issue.Severity.String()+" "+issue.String())
+	}
+	wantIssues := []string{
+		`error Service/web: spec.ports[2]: targetPort TCP/"admin" is not exposed by any selected pod`,
+		`warning Service/web: spec.ports[3]: targetPort UDP/53 is not exposed by any selected pod`,
+	}
+	if strings.Join(issues, "\n") != strings.Join(wantIssues, "\n") {
+		t.Errorf("Expected issues:\n%s\ngot:\n%s", strings.Join(wantIssues, "\n"), strings.Join(issues, "\n"))
+	}
+
+	if ports := g.ServicePorts(g.Nodes()[1]); ports != nil {
+		t.Errorf("Expected no port map for a Deployment, got %v", ports)
+	}
+}
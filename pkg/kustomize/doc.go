@@ -0,0 +1,21 @@
+/*
+Package kustomize builds kustomize overlays in-process so they can be
+previewed without a separate kustomize build step.
+
+The build runs on the local filesystem with kustomize's default options, so
+remote bases, Helm chart inflation and plugins are not available. Each
+resulting resource records in Source.Kustomize the file that declared it
+(such as ../../base/deployment.yaml, or the kustomization.yaml holding a
+generator) and the transformers that modified it.
+
+Example Usage:
+
+	result, err := kustomize.Load("overlays/prod")
+	if err != nil {
+	    log.Fatal(err)
+	}
+	for _, res := range result.Resources {
+	    fmt.Printf("%s/%s from %s\n", res.Kind, res.Metadata.Name, res.Source.Kustomize.Origin)
+	}
+*/
+package kustomize
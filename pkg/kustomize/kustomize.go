@@ -0,0 +1,147 @@
+package kustomize
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"k8spreview/pkg/k8s"
+)
+
+// Load runs an in-process kustomize build of the kustomization in dir on the
+// local filesystem and parses the result. Each resource records dir as its
+// file and, in Source.Kustomize, the file that declared it and the
+// kustomizations and patches that transformed it.
+//
+// Origin and transformer annotations are enabled for the build to obtain
+// this provenance; they are only left on the resources if the kustomization
+// requested them itself.
+func Load(dir string) (*k8s.Result, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %w", dir, err)
+	}
+	fs := &buildMetadataFS{FileSystem: filesys.MakeFsOnDisk(), root: abs}
+	k := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resMap, err := k.Run(fs, abs)
+	if err != nil {
+		return nil, fmt.Errorf("error building kustomization: %w", err)
+	}
+
+	provenance := make([]*k8s.KustomizeSource, resMap.Size())
+	for i, r := range resMap.Resources() {
+		src := &k8s.KustomizeSource{}
+		if origin, err := r.GetOrigin(); err == nil && origin != nil {
+			src.Origin = originString(origin.Path, origin.ConfiguredIn, origin.Repo)
+		}
+		if transformations, err := r.GetTransformations(); err == nil {
+			for _, t := range transformations {
+				name := originString(t.Path, t.ConfiguredIn, t.Repo)
+				if t.ConfiguredBy.Kind != "" {
+					name += fmt.Sprintf(" (%s)", t.ConfiguredBy.Kind)
+				}
+				src.Transformations = append(src.Transformations, name)
+			}
+		}
+		provenance[i] = src
+	}
+	if !fs.keepOrigin {
+		if err := resMap.RemoveOriginAnnotations(); err != nil {
+			return nil, fmt.Errorf("error removing origin annotations: %w", err)
+		}
+	}
+	if !fs.keepTransformations {
+		if err := resMap.RemoveTransformerAnnotations(); err != nil {
+			return nil, fmt.Errorf("error removing transformer annotations: %w", err)
+		}
+	}
+
+	out, err := resMap.AsYaml()
+	if err != nil {
+		return nil, fmt.Errorf("error encoding kustomize output: %w", err)
+	}
+	result, err := k8s.Load(bytes.NewReader(out), dir)
+	if err != nil {
+		return nil, err
+	}
+	// Every kustomize output document is an object, so resources line up
+	// with the resource map one to one
+	if len(result.Resources) == len(provenance) {
+		for i := range result.Resources {
+			result.Resources[i].Source.Kustomize = provenance[i]
+		}
+	}
+	return result, nil
+}
+
+// originString formats a kustomize origin, preferring the file that
+// configured a generator over the generator's own path
+func originString(path, configuredIn, repo string) string {
+	if configuredIn != "" {
+		path = configuredIn
+	}
+	if repo != "" {
+		return repo + "//" + path
+	}
+	return path
+}
+
+// buildMetadataFS enables origin and transformer annotations on the root
+// kustomization as it is read, which kustomize propagates to all bases
+type buildMetadataFS struct {
+	filesys.FileSystem
+	root string
+
+	keepOrigin          bool // Root kustomization requested originAnnotations
+	keepTransformations bool // Root kustomization requested transformerAnnotations
+}
+
+// ReadFile implements filesys.FileSystem
+func (fs *buildMetadataFS) ReadFile(path string) ([]byte, error) {
+	data, err := fs.FileSystem.ReadFile(path)
+	if err != nil || !fs.isRootKustomization(path) {
+		return data, err
+	}
+
+	var kustomization map[string]interface{}
+	if err := yaml.Unmarshal(data, &kustomization); err != nil || kustomization == nil {
+		// Let kustomize report the error
+		return data, nil
+	}
+	metadata, _ := kustomization["buildMetadata"].([]interface{})
+	for _, m := range metadata {
+		switch m {
+		case types.OriginAnnotations:
+			fs.keepOrigin = true
+		case types.TransformerAnnotations:
+			fs.keepTransformations = true
+		}
+	}
+	if !fs.keepOrigin {
+		metadata = append(metadata, types.OriginAnnotations)
+	}
+	if !fs.keepTransformations {
+		metadata = append(metadata, types.TransformerAnnotations)
+	}
+	kustomization["buildMetadata"] = metadata
+	return yaml.Marshal(kustomization)
+}
+
+func (fs *buildMetadataFS) isRootKustomization(path string) bool {
+	if filepath.Dir(filepath.Clean(path)) != fs.root {
+		return false
+	}
+	base := filepath.Base(path)
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package kustomize_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8spreview/pkg/kustomize"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base/kustomization.yaml": `resources:
+  - deployment.yaml
+`,
+		"base/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx
+`,
+		"overlays/prod/kustomization.yaml": `namespace: prod
+resources:
+  - ../../base
+patches:
+  - path: replicas.yaml
+configMapGenerator:
+  - name: settings
+    literals:
+      - LOG_LEVEL=info
+`,
+		"overlays/prod/replicas.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+`,
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	overlay := filepath.Join(dir, "overlays/prod")
+	result, err := kustomize.Load(overlay)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(result.Resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %d", len(result.Resources))
+	}
+
+	for _, res := range result.Resources {
+		if res.Metadata.Namespace != "prod" {
+			t.Errorf("Expected namespace prod on %s, got %q", res.Kind, res.Metadata.Namespace)
+		}
+		if len(res.Metadata.Annotations) != 0 {
+			t.Errorf("Expected provenance annotations to be removed, got %v", res.Metadata.Annotations)
+		}
+		if res.Source.File != overlay || res.Source.Kustomize == nil {
+			t.Fatalf("Expected kustomize provenance for %s, got %v", res.Kind, res.Source)
+		}
+
+		switch res.Kind {
+		case "Deployment":
+			if replicas, _ := res.Get("spec", "replicas"); replicas != 3 {
+				t.Errorf("Expected patched replicas 3, got %v", replicas)
+			}
+			if res.Source.Kustomize.Origin != "../../base/deployment.yaml" {
+				t.Errorf("Unexpected origin %q", res.Source.Kustomize.Origin)
+			}
+			if !strings.Contains(strings.Join(res.Source.Kustomize.Transformations, ","), "kustomization.yaml (PatchTransformer)") {
+				t.Errorf("Expected patch in transformations, got %v", res.Source.Kustomize.Transformations)
+			}
+		case "ConfigMap":
+			if !strings.HasPrefix(res.Metadata.Name, "settings-") {
+				t.Errorf("Expected generated ConfigMap name, got %s", res.Metadata.Name)
+			}
+			if res.Source.Kustomize.Origin != "kustomization.yaml" {
+				t.Errorf("Unexpected origin %q", res.Source.Kustomize.Origin)
+			}
+		default:
+			t.Errorf("Unexpected resource %s", res.Kind)
+		}
+	}
+}
@@ -10,25 +10,29 @@ import (
 
 // Run starts the UI application
 func Run(yamlPath string) error {
-	resources, err := k8s.ParseFromFile(yamlPath)
+	result, err := k8s.LoadFile(yamlPath)
 	if err != nil {
 		return fmt.Errorf("failed to parse YAML file: %w", err)
 	}
-
-	p := tea.NewProgram(
-		NewModel(resources),
-		tea.WithAltScreen(),
-		tea.WithMouseCellMotion(),
-	)
-
-	_, err = p.Run()
-	return err
+	return RunWithResult(result)
 }
 
 // RunWithResources starts the UI application with pre-parsed resources
 func RunWithResources(resources []k8s.Resource) error {
+	return RunWithResult(&k8s.Result{Resources: resources})
+}
+
+// RunWithResult starts the UI application with the outcome of k8s.Load,
+// listing documents that failed to parse next to the valid resources
+func RunWithResult(result *k8s.Result) error {
+	return RunWithOptions(result, k8s.GraphOptions{})
+}
+
+// RunWithOptions starts the UI application, computing relationships with the
+// given graph options
+func RunWithOptions(result *k8s.Result, opts k8s.GraphOptions) error {
 	p := tea.NewProgram(
-		NewModel(resources),
+		NewModelWithOptions(result, opts),
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
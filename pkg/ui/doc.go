@@ -8,11 +8,16 @@ Views:
   - List View: Shows all resources in a scrollable, filterable list
   - Detail View: Shows YAML representation and relationships of a selected resource
   - Graph View: Visual representation of resource relationships
+  - Network View: NetworkPolicy reachability of the selected workload and a
+    matrix of all workloads
+  - Tree View: Collapsible ownership tree nesting objects beneath the owners
+    named in their ownerReferences
 
 Features:
   - Color-coded resource types for better visibility
   - Interactive navigation using keyboard
   - Resource filtering
+  - Documents that failed to parse are listed as red "parse error" items
   - Relationship visualization
   - YAML content viewing
   - Resource graph generation
@@ -21,6 +26,12 @@ Navigation:
   - Arrow keys: Navigate through resources
   - Enter: View resource details
   - g: View relationship graph
+  - s: Show only storage resources in the graph view
+  - n: View NetworkPolicy analysis
+  - t: View the ownership tree; enter or ←/→ expand and collapse
+  - r: Toggle original/normalized YAML in the detail view
+  - e: Export the resource from the detail view
+  - o: Show/hide documents that are not Kubernetes objects
   - /: Filter resources
   - q: Go back/quit
 
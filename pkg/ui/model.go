@@ -2,13 +2,13 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"gopkg.in/yaml.v3"
 
 	"k8spreview/pkg/k8s"
 )
@@ -19,17 +19,30 @@ const (
 	listView view = iota
 	detailView
 	graphView
+	networkView
+	treeView
 )
 
 // Model represents the UI state
 type Model struct {
-	resources []k8s.Resource
-	list      list.Model
-	selected  *k8s.Resource
-	view      view
-	viewport  viewport.Model
-	width     int
-	height    int
+	resources    []k8s.Resource
+	result       *k8s.Result
+	graph        *k8s.Graph        // Relationships, computed once at load time
+	graphContent string            // Rendered graph view
+	storageOnly  bool              // Whether the graph view only shows storage
+	network      *k8s.Network      // NetworkPolicy evaluation, computed on first use
+	ownerTree    []*k8s.OwnerNode  // Ownership tree, computed on first use
+	collapsed    map[k8s.Node]bool // Tree nodes whose owned objects are hidden
+	treeCursor   int               // Index of the selected tree line
+	showOthers   bool              // Whether non-Kubernetes documents are listed
+	showOriginal bool              // Whether the detail view shows the original text
+	status       string            // Message shown at the top of the detail view
+	list         list.Model
+	selected     *k8s.Resource
+	view         view
+	viewport     viewport.Model
+	width        int
+	height       int
 }
 
 // Item represents a list item in the UI
@@ -37,29 +50,45 @@ type item struct {
 	title       string
 	description string
 	resource    k8s.Resource
+	parseErr    *k8s.DocumentError // Set for documents that failed to parse
+	other       *k8s.Document      // Set for documents that are not Kubernetes objects
 }
 
 func (i item) Title() string       { return i.title }
 func (i item) Description() string { return i.description }
-func (i item) FilterValue() string { return i.resource.Kind + "/" + i.resource.Metadata.Name }
+func (i item) FilterValue() string {
+	if i.parseErr != nil {
+		return "parse error " + i.parseErr.Source.String()
+	}
+	if i.other != nil {
+		return "other " + i.other.Source.String()
+	}
+	return i.resource.Kind + "/" + i.resource.Metadata.Name
+}
 
 // NewModel creates a new UI model
 func NewModel(resources []k8s.Resource) Model {
-	items := make([]list.Item, len(resources))
-	for i, res := range resources {
-		items[i] = item{
-			title:       fmt.Sprintf("%s/%s", res.Kind, res.Metadata.Name),
-                        description: fmt.Sprintf("API Version: %s, Namespace: %s", res.APIVersion, res.Metadata.Namespace),
-			resource:    res,
-		}
-	}
+	return NewModelFromResult(&k8s.Result{Resources: resources})
+}
+
+// NewModelFromResult creates a new UI model that also lists the documents
+// that failed to parse
+func NewModelFromResult(result *k8s.Result) Model {
+	return NewModelWithOptions(result, k8s.GraphOptions{})
+}
+
+// NewModelWithOptions creates a new UI model, computing relationships with
+// the given graph options
+func NewModelWithOptions(result *k8s.Result, opts k8s.GraphOptions) Model {
+	resources := result.Resources
+	items := buildItems(result, false)
 
 	delegate := list.NewDefaultDelegate()
 	delegate.Styles.NormalTitle = delegate.Styles.NormalTitle.Copy().Foreground(lipgloss.NoColor{})
 	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Copy().Foreground(lipgloss.Color("white"))
 
 	l := list.New(items, delegate, 0, 0)
-	l.Title = "Kubernetes Resources"
+	l.Title = listTitle(result, false)
 	l.Styles.Title = TitleStyle
 	l.FilterInput.Prompt = "Filter: "
 	l.SetShowStatusBar(true)
@@ -74,10 +103,56 @@ func NewModel(resources []k8s.Resource) Model {
 		PaddingRight(2)
 
 	return Model{
-		resources: resources,
-		list:      l,
-		viewport:  vp,
-		view:      listView,
+		resources:    resources,
+		result:       result,
+		graph:        k8s.BuildGraphWithOptions(resources, opts),
+		showOriginal: true,
+		list:         l,
+		viewport:     vp,
+		view:         listView,
+	}
+}
+
+// buildItems creates the list items for the resources and broken documents,
+// followed by the non-Kubernetes documents when showOthers is set
+func buildItems(result *k8s.Result, showOthers bool) []list.Item {
+	items := make([]list.Item, 0, len(result.Resources)+len(result.Errors)+len(result.Others))
+	for _, res := range result.Resources {
+		items = append(items, item{
+			title:       fmt.Sprintf("%s/%s", res.Kind, res.Metadata.Name),
+			description: fmt.Sprintf("API Version: %s, Namespace: %s, Source: %s", res.APIVersion, res.Metadata.Namespace, res.Source),
+			resource:    res,
+		})
+	}
+	for _, docErr := range result.Errors {
+		items = append(items, item{
+			title:       ErrorStyle.Render("parse error"),
+			description: fmt.Sprintf("Source: %s, line %d", docErr.Source, docErr.Line),
+			parseErr:    docErr,
+		})
+	}
+	if showOthers {
+		for i := range result.Others {
+			doc := &result.Others[i]
+			items = append(items, item{
+				title:       OtherStyle.Render("Other document"),
+				description: fmt.Sprintf("Source: %s", doc.Source),
+				other:       doc,
+			})
+		}
+	}
+	return items
+}
+
+// listTitle returns the list title, mentioning the other documents section
+func listTitle(result *k8s.Result, showOthers bool) string {
+	switch {
+	case len(result.Others) == 0:
+		return "Kubernetes Resources"
+	case showOthers:
+		return fmt.Sprintf("Kubernetes Resources + Other documents (%d)", len(result.Others))
+	default:
+		return fmt.Sprintf("Kubernetes Resources (%d other documents hidden, press o to show)", len(result.Others))
 	}
 }
 
@@ -90,40 +165,109 @@ func (m Model) Init() tea.Cmd {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if msg, ok := msg.(tea.KeyMsg); ok && m.view == treeView && msg.String() != "q" {
+		return m.updateTree(msg), nil
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "q":
-			if m.view == detailView || m.view == graphView {
+			if m.view == detailView || m.view == graphView || m.view == networkView || m.view == treeView {
 				m.view = listView
 				return m, nil
 			}
 			return m, tea.Quit
 		case "enter":
 			if m.view == listView {
-				if i, ok := m.list.SelectedItem().(item); ok {
-					m.selected = &i.resource
+				i, ok := m.list.SelectedItem().(item)
+				if ok && i.parseErr != nil {
+					m.selected = nil
 					m.view = detailView
-
-					// Create detailed view with relationships
-					yamlData, _ := yaml.Marshal(m.selected)
-					content := string(yamlData)
-
-					// Add relationships section
-					relations := m.selected.FindRelatedResources(m.resources)
-					if len(relations) > 0 {
-						content += "\n\nRelationships:\n"
-						for _, rel := range relations {
-							content += RelationshipStyle.Render(fmt.Sprintf("  %s\n", rel))
-						}
-					}
-
+					content := SourceStyle.Render("Source: "+i.parseErr.Source.String()) + "\n\n"
+					content += ErrorStyle.Render(i.parseErr.Error()) + "\n\n"
+					content += i.parseErr.Raw
 					m.viewport.SetContent(content)
+				} else if ok && i.other != nil {
+					m.selected = nil
+					m.view = detailView
+					content := SourceStyle.Render("Source: "+i.other.Source.String()) + "\n\n"
+					content += OtherStyle.Render("Not a Kubernetes object (no kind or apiVersion)") + "\n\n"
+					content += i.other.Raw
+					m.viewport.SetContent(content)
+				} else if ok {
+					m.selected = &i.resource
+					m.view = detailView
+					m.status = ""
+					m.viewport.SetContent(m.resourceDetail())
 				}
 			}
+		case "r":
+			if m.view == detailView && m.selected != nil {
+				m.showOriginal = !m.showOriginal
+				m.status = ""
+				m.viewport.SetContent(m.resourceDetail())
+				return m, nil
+			}
+		case "e":
+			if m.view == detailView && m.selected != nil {
+				filename, err := k8s.ExportAs(*m.selected, m.exportFormat())
+				if err != nil {
+					m.status = ErrorStyle.Render(fmt.Sprintf("Export failed: %v", err))
+				} else {
+					m.status = StatusStyle.Render(fmt.Sprintf("Exported to %s", filename))
+				}
+				m.viewport.SetContent(m.resourceDetail())
+				return m, nil
+			}
 		case "g":
 			if m.view == listView {
 				m.view = graphView
+				// Render once per visit rather than on every keypress
+				m.graphContent = m.generateGraph()
+			}
+		case "s":
+			if m.view == graphView {
+				m.storageOnly = !m.storageOnly
+				m.graphContent = m.generateGraph()
+				return m, nil
+			}
+		case "o":
+			if m.view == listView && m.list.FilterState() != list.Filtering && len(m.result.Others) > 0 {
+				m.showOthers = !m.showOthers
+				m.list.Title = listTitle(m.result, m.showOthers)
+				cmd = m.list.SetItems(buildItems(m.result, m.showOthers))
+				return m, cmd
+			}
+		case "n":
+			if m.view == listView && m.list.FilterState() != list.Filtering {
+				if m.network == nil {
+					m.network = m.graph.Network()
+				}
+				var focus *k8s.Node
+				if i, ok := m.list.SelectedItem().(item); ok && i.parseErr == nil && i.other == nil {
+					n := m.graph.Node(i.resource)
+					focus = &n
+				}
+				m.view = networkView
+				m.viewport.SetContent(m.networkContent(focus))
+				m.viewport.GotoTop()
+				return m, nil
+			}
+		case "t":
+			if m.view == listView && m.list.FilterState() != list.Filtering {
+				if m.ownerTree == nil {
+					m.ownerTree = m.graph.OwnerTree()
+					m.collapsed = make(map[k8s.Node]bool)
+				}
+				m.treeCursor = 0
+				if i, ok := m.list.SelectedItem().(item); ok && i.parseErr == nil && i.other == nil {
+					m.focusTree(m.graph.Node(i.resource))
+				}
+				m.view = treeView
+				m.viewport.GotoTop()
+				m.setTreeContent()
+				return m, nil
 			}
 		case "/":
 			m.list.ShowFilter()
@@ -140,7 +284,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.view == listView {
 		m.list, cmd = m.list.Update(msg)
 		return m, cmd
-	} else if m.view == detailView {
+	} else if m.view == detailView || m.view == networkView {
 		m.viewport, cmd = m.viewport.Update(msg)
 		return m, cmd
 	}
@@ -148,6 +292,159 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// exportFormat returns the format currently shown in the detail view
+func (m Model) exportFormat() k8s.ExportFormat {
+	if m.showOriginal {
+		return k8s.Original
+	}
+	return k8s.Normalized
+}
+
+// resourceDetail renders the selected resource with its relationships
+func (m Model) resourceDetail() string {
+	format := "normalized"
+	if m.showOriginal {
+		format = "original"
+	}
+	content := SourceStyle.Render(fmt.Sprintf("Source: %s  [%s, r: toggle original/normalized, e: export]", m.selected.Source, format)) + "\n"
+	if m.status != "" {
+		content += m.status + "\n"
+	}
+	content += "\n"
+
+	yamlData, err := m.selected.Text(m.exportFormat())
+	if err != nil {
+		content += ErrorStyle.Render(err.Error())
+	}
+	content += strings.TrimRight(string(yamlData), "\n")
+
+	// Add relationships section
+	node := m.graph.Node(*m.selected)
+	out, in := m.graph.Out(node), m.graph.In(node)
+	if len(out)+len(in) > 0 {
+		content += "\n\nRelationships:\n"
+		for _, e := range out {
+			if e.Dangling {
+				content += ErrorStyle.Render(fmt.Sprintf("  → %s %s (missing)", e.Type.Verb(), e.To))
+			} else {
+				content += RelationshipStyle.Render(fmt.Sprintf("  → %s %s", e.Type.Verb(), e.To))
+			}
+			content += SourceStyle.Render("  "+edgeDetail(e)) + "\n"
+		}
+		for _, e := range in {
+			content += RelationshipStyle.Render(fmt.Sprintf("  ← %s %s", e.Type.PassiveVerb(), e.From))
+			content += SourceStyle.Render("  "+edgeDetail(e)) + "\n"
+		}
+	}
+
+	if m.selected.Kind == "ServiceAccount" {
+		content += permissionsPanel(m.graph.Permissions(node))
+	}
+	if m.selected.Kind == "Service" {
+		content += portsPanel(m.graph.ServicePorts(node))
+	}
+
+	if issues := m.graph.IssuesFor(node); len(issues) > 0 {
+		content += "\nIssues:\n"
+		for _, i := range issues {
+			style := WarningStyle
+			if i.Severity == k8s.Error {
+				style = ErrorStyle
+			}
+			content += style.Render(fmt.Sprintf("  %s: %s", i.Severity, i.Message))
+			if i.Field != "" {
+				content += SourceStyle.Render("  " + i.Field)
+			}
+			content += "\n"
+		}
+	}
+	return content
+}
+
+// permissionsPanel renders the effective permissions of a ServiceAccount,
+// grouped by the namespace they apply in
+func permissionsPanel(perms []k8s.Permission) string {
+	content := "\nEffective permissions:\n"
+	if len(perms) == 0 {
+		return content + SourceStyle.Render("  none granted by the loaded bindings") + "\n"
+	}
+	width := 0
+	for _, p := range perms {
+		width = max(width, len(permissionResource(p)))
+	}
+	scope := "\x00"
+	for _, p := range perms {
+		if p.Namespace != scope {
+			scope = p.Namespace
+			if scope == "" {
+				content += NamespaceStyle.Render("  cluster-wide") + "\n"
+			} else {
+				content += NamespaceStyle.Render("  namespace "+scope) + "\n"
+			}
+		}
+		content += fmt.Sprintf("    %-*s  %s", width, permissionResource(p), RelationshipStyle.Render(strings.Join(p.Verbs, ", ")))
+		content += SourceStyle.Render("  via "+strings.Join(p.Via, "; ")) + "\n"
+	}
+	return content
+}
+
+// portsPanel renders the port map of a Service: each port, its targetPort
+// and the container port serving it on every selected workload
+func portsPanel(ports []k8s.ServicePort) string {
+	if len(ports) == 0 {
+		return ""
+	}
+	content := "\nPorts:\n"
+	for _, p := range ports {
+		port := fmt.Sprintf("%s/%d", p.Protocol, p.Port)
+		if p.Name != "" {
+			port = p.Name + " " + port
+		}
+		content += fmt.Sprintf("  %s → %s", port, p.TargetPort)
+		content += SourceStyle.Render("  "+p.Field) + "\n"
+		if len(p.Backends) == 0 {
+			content += SourceStyle.Render("    no selected pods") + "\n"
+		}
+		for _, b := range p.Backends {
+			if !b.Exposed() {
+				content += WarningStyle.Render(fmt.Sprintf("    %s: not exposed", b.Workload)) + "\n"
+				continue
+			}
+			target := fmt.Sprintf("%s:%d", b.Container, b.Port)
+			if b.Name != "" {
+				target += " (" + b.Name + ")"
+			}
+			content += RelationshipStyle.Render(fmt.Sprintf("    %s: %s", b.Workload, target)) + "\n"
+		}
+	}
+	return content
+}
+
+// permissionResource formats the resource of a permission, with its
+// resource names if it is restricted to some
+func permissionResource(p k8s.Permission) string {
+	if len(p.ResourceNames) == 0 {
+		return p.Resource
+	}
+	return fmt.Sprintf("%s [%s]", p.Resource, strings.Join(p.ResourceNames, ", "))
+}
+
+// edgeDetail describes where an edge comes from: its field path and, for
+// pod spec references, the container and key
+func edgeDetail(e k8s.Edge) string {
+	var details []string
+	if e.Container != "" {
+		details = append(details, "container "+e.Container)
+	}
+	if e.Key != "" {
+		details = append(details, "key "+e.Key)
+	}
+	if len(details) == 0 {
+		return e.Field
+	}
+	return fmt.Sprintf("%s (%s)", e.Field, strings.Join(details, ", "))
+}
+
 // View renders the UI
 func (m Model) View() string {
 	switch m.view {
@@ -156,55 +453,35 @@ func (m Model) View() string {
 	case detailView:
 		return m.viewport.View()
 	case graphView:
-		return m.generateGraph()
+		return m.graphContent
+	case networkView, treeView:
+		return m.viewport.View()
 	default:
 		return ""
 	}
 }
 
-type relationship struct {
-	from     string
-	to       string
-	relation string
-}
-
-// generateGraph creates a visual representation of resource relationships
+// generateGraph creates a visual representation of resource relationships,
+// grouped by namespace
 func (m Model) generateGraph() string {
-	var relationships []relationship
-	resourcesByType := make(map[string][]string)
-
-	// Collect all relationships and organize resources by type
-	for _, resource := range m.resources {
-		nodeKey := fmt.Sprintf("%s/%s", resource.Kind, resource.Metadata.Name)
-		resourcesByType[resource.Kind] = append(resourcesByType[resource.Kind], nodeKey)
-
-		relations := resource.FindRelatedResources(m.resources)
-		for _, rel := range relations {
-			var r relationship
-			if strings.HasPrefix(rel, "→") {
-				parts := strings.Split(strings.TrimPrefix(rel, "→ "), "/")
-				if len(parts) == 2 {
-					r = relationship{
-						from:     nodeKey,
-						to:       fmt.Sprintf("%s/%s", parts[0], strings.TrimSpace(parts[1])),
-						relation: "uses",
-					}
-				}
-			} else if strings.HasPrefix(rel, "←") {
-				parts := strings.Split(strings.TrimPrefix(rel, "← Selected by "), "/")
-				if len(parts) == 2 {
-					r = relationship{
-						from:     fmt.Sprintf("%s/%s", parts[0], strings.TrimSpace(parts[1])),
-						to:       nodeKey,
-						relation: "selects",
-					}
-				}
-			}
-			if r.from != "" && r.to != "" {
-				relationships = append(relationships, r)
-			}
+	nodes := m.graph.Nodes()
+	edges := append([]k8s.Edge(nil), m.graph.Edges()...)
+	k8s.SortEdges(edges)
+	if m.storageOnly {
+		nodes, edges = storageSubgraph(nodes, edges)
+	}
+
+	resourcesByType := make(map[string][]k8s.Node)
+	byNamespace := make(map[string]map[string][]k8s.Node)
+	for _, n := range nodes {
+		resourcesByType[n.Kind] = append(resourcesByType[n.Kind], n)
+		if byNamespace[n.Namespace] == nil {
+			byNamespace[n.Namespace] = make(map[string][]k8s.Node)
 		}
+		byNamespace[n.Namespace][n.Kind] = append(byNamespace[n.Namespace][n.Kind], n)
 	}
+	kinds := sortedKeys(resourcesByType)
+	namespaces := sortedKeys(byNamespace)
 
 	var sb strings.Builder
 
@@ -216,65 +493,125 @@ func (m Model) generateGraph() string {
 		Padding(0, 1).
 		MarginBottom(1)
 
-	sb.WriteString("\n" + titleStyle.Render("Kubernetes Resource Graph") + "\n\n")
+	title := "Kubernetes Resource Graph"
+	if m.storageOnly {
+		title += " (storage)"
+	}
+	sb.WriteString("\n" + titleStyle.Render(title) + "\n\n")
 
 	// Legend
 	sb.WriteString("Legend:\n")
-	for kind, style := range ResourceStyles {
-		if len(resourcesByType[kind]) > 0 {
-			sb.WriteString(fmt.Sprintf("  %s: %s\n",
-				style.Render(fmt.Sprintf("%-10s", kind)),
-				style.Render("●")))
-		}
+	for _, kind := range kinds {
+		style := ResourceStyles[kind]
+		sb.WriteString(fmt.Sprintf("  %s: %s\n",
+			style.Render(fmt.Sprintf("%-10s", kind)),
+			style.Render("●")))
 	}
 	sb.WriteString("  Relationships:\n")
-	sb.WriteString(fmt.Sprintf("    %s: Resource uses/references\n",
-		GraphEdgeStyle.Render("A ────uses────> B")))
-	sb.WriteString(fmt.Sprintf("    %s: Resource selects/targets\n",
-		GraphEdgeStyle.Render("A <──selects──── B")))
+	sb.WriteString(fmt.Sprintf("    %s: A selects, mounts, uses, binds, aggregates, routes to, attaches to, permits, scales or is owned by B\n",
+		GraphEdgeStyle.Render("A ──type──> B")))
+	sb.WriteString(fmt.Sprintf("    %s: B is not among the loaded resources\n",
+		ErrorStyle.Render("A ──type──> B")))
 	sb.WriteString("\n")
 
-	// Resources by type
-	sb.WriteString("Resources:\n")
-	for kind := range ResourceStyles {
-		resources := resourcesByType[kind]
-		if len(resources) > 0 {
+	// Connections by the namespace of their source, once per pair of
+	// resources and edge type
+	edgesByNamespace := make(map[string][]k8s.Edge)
+	for _, e := range edges {
+		edgesByNamespace[e.From.Namespace] = append(edgesByNamespace[e.From.Namespace], e)
+	}
+
+	// Resources by namespace and type
+	for _, ns := range namespaces {
+		sb.WriteString(NamespaceStyle.Render(namespaceTitle(ns)) + "\n")
+		for _, kind := range sortedKeys(byNamespace[ns]) {
 			style := ResourceStyles[kind]
 			sb.WriteString(fmt.Sprintf("  %s\n", style.Render(kind)))
-			for _, res := range resources {
-				name := strings.Split(res, "/")[1]
+			for _, n := range byNamespace[ns][kind] {
 				sb.WriteString(fmt.Sprintf("    %s %s\n",
 					style.Render("●"),
-					style.Render(name)))
+					style.Render(n.Name)))
 			}
 		}
-	}
-
-	// Relationships
-	if len(relationships) > 0 {
-		sb.WriteString("\nConnections:\n")
-		for _, rel := range relationships {
-			fromParts := strings.Split(rel.from, "/")
-			toParts := strings.Split(rel.to, "/")
 
-			fromStyle := ResourceStyles[fromParts[0]]
-			toStyle := ResourceStyles[toParts[0]]
+		if len(edgesByNamespace[ns]) > 0 {
+			sb.WriteString("  Connections:\n")
+			seen := make(map[string]bool)
+			for _, e := range edgesByNamespace[ns] {
+				id := fmt.Sprintf("%v|%v|%s", e.From, e.To, e.Type)
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
 
-			var arrow string
-			if rel.relation == "uses" {
-				arrow = "────uses────>"
-			} else {
-				arrow = "<──selects────"
+				to := e.To.String()
+				if e.To.Namespace != ns && e.To.Namespace != "" {
+					to = e.To.Namespace + "/" + to
+				}
+				edgeStyle, toStyle := GraphEdgeStyle, ResourceStyles[e.To.Kind]
+				if e.Dangling {
+					edgeStyle, toStyle = ErrorStyle, ErrorStyle
+					to += " (missing)"
+				}
+				line := fmt.Sprintf("    %s %s %s",
+					ResourceStyles[e.From.Kind].Render(e.From.String()),
+					edgeStyle.Render(fmt.Sprintf("──%s──>", e.Type)),
+					toStyle.Render(to))
+				sb.WriteString(line + "\n")
 			}
-
-			line := fmt.Sprintf("  %s %s %s",
-				fromStyle.Render(fromParts[1]),
-				GraphEdgeStyle.Render(arrow),
-				toStyle.Render(toParts[1]))
-			sb.WriteString(line + "\n")
 		}
+		sb.WriteString("\n")
 	}
 
-	sb.WriteString("\nPress 'q' to return to list view")
+	if m.storageOnly {
+		sb.WriteString("Press 's' to show all resources, 'q' to return to list view")
+	} else {
+		sb.WriteString("Press 's' to show only storage, 'q' to return to list view")
+	}
 	return sb.String()
 }
+
+// storageKinds are the kinds shown by the storage filter of the graph view
+var storageKinds = map[string]bool{
+	"PersistentVolumeClaim": true,
+	"PersistentVolume":      true,
+	"StorageClass":          true,
+}
+
+// storageSubgraph keeps the storage resources, the edges touching them and
+// the resources at the other end of those edges
+func storageSubgraph(nodes []k8s.Node, edges []k8s.Edge) ([]k8s.Node, []k8s.Edge) {
+	keep := make(map[k8s.Node]bool)
+	var storageEdges []k8s.Edge
+	for _, e := range edges {
+		if storageKinds[e.From.Kind] || storageKinds[e.To.Kind] {
+			storageEdges = append(storageEdges, e)
+			keep[e.From], keep[e.To] = true, true
+		}
+	}
+	var storageNodes []k8s.Node
+	for _, n := range nodes {
+		if storageKinds[n.Kind] || keep[n] {
+			storageNodes = append(storageNodes, n)
+		}
+	}
+	return storageNodes, storageEdges
+}
+
+// namespaceTitle returns the heading for a namespace in the graph view
+func namespaceTitle(ns string) string {
+	if ns == "" {
+		return "Cluster-scoped"
+	}
+	return "Namespace: " + ns
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"k8spreview/pkg/k8s"
+)
+
+// networkContent renders the NetworkPolicy analysis: the traffic to and from
+// focus, if it is a workload, followed by the matrix of all workloads
+func (m Model) networkContent(focus *k8s.Node) string {
+	var sb strings.Builder
+	sb.WriteString(TitleStyle.Render("NetworkPolicy Analysis") + "\n\n")
+
+	workloads := m.network.Workloads
+	if len(workloads) == 0 {
+		sb.WriteString(SourceStyle.Render("No workloads or pods loaded") + "\n")
+		return sb.String()
+	}
+
+	if focus != nil && isWorkload(workloads, *focus) {
+		sb.WriteString(fmt.Sprintf("%s %s\n\n", ResourceStyles[focus.Kind].Render(focus.String()), SourceStyle.Render("in "+focus.Namespace)))
+		sb.WriteString("May be reached by:\n")
+		sb.WriteString(trafficLines(m.network.Ingress(*focus), func(t k8s.Traffic) k8s.Node { return t.From }))
+		sb.WriteString("\nMay reach:\n")
+		sb.WriteString(trafficLines(m.network.Egress(*focus), func(t k8s.Traffic) k8s.Node { return t.To }))
+		sb.WriteString("\n")
+	}
+
+	// Matrix, rows may reach columns
+	sb.WriteString("Matrix (row may reach column):\n")
+	header := "     "
+	for i := range workloads {
+		header += fmt.Sprintf("%3d", i+1)
+	}
+	sb.WriteString(SourceStyle.Render(header) + "\n")
+	for i, from := range workloads {
+		sb.WriteString(SourceStyle.Render(fmt.Sprintf("  %3d", i+1)))
+		for _, to := range workloads {
+			t, ok := m.network.Allowed(from, to)
+			switch {
+			case !ok:
+				sb.WriteString(SourceStyle.Render("  ·"))
+			case t.AllPorts():
+				sb.WriteString(StatusStyle.Render("  ●"))
+			default:
+				sb.WriteString(WarningStyle.Render("  ◐"))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fmt.Sprintf("\n  %s all ports  %s some ports  %s denied\n\n",
+		StatusStyle.Render("●"), WarningStyle.Render("◐"), SourceStyle.Render("·")))
+	for i, w := range workloads {
+		sb.WriteString(fmt.Sprintf("  %3d %s %s\n", i+1, ResourceStyles[w.Kind].Render(w.String()), SourceStyle.Render(w.Namespace)))
+	}
+
+	sb.WriteString("\nPress 'q' to return to list view")
+	return sb.String()
+}
+
+// trafficLines lists traffic with its peer, ports and the egress and ingress
+// policies allowing it
+func trafficLines(traffic []k8s.Traffic, peer func(k8s.Traffic) k8s.Node) string {
+	if len(traffic) == 0 {
+		return SourceStyle.Render("  nothing") + "\n"
+	}
+	var sb strings.Builder
+	for _, t := range traffic {
+		n := peer(t)
+		policies := append(t.Egress[:len(t.Egress):len(t.Egress)], t.Ingress...)
+		ports := "all ports"
+		if !t.AllPorts() {
+			ports = strings.Join(t.Ports, ", ")
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s", ResourceStyles[n.Kind].Render(n.String()), RelationshipStyle.Render(ports)))
+		if n.Namespace != "" {
+			sb.WriteString(SourceStyle.Render("  in " + n.Namespace))
+		}
+		if len(policies) > 0 {
+			names := make([]string, len(policies))
+			for i, p := range policies {
+				names[i] = p.Name
+			}
+			sb.WriteString(SourceStyle.Render("  via " + strings.Join(names, ", ")))
+		} else {
+			sb.WriteString(SourceStyle.Render("  not isolated"))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// isWorkload reports whether n is one of the analyzed workloads
+func isWorkload(workloads []k8s.Node, n k8s.Node) bool {
+	for _, w := range workloads {
+		if w == n {
+			return true
+		}
+	}
+	return false
+}
@@ -13,6 +13,33 @@ var (
 				Foreground(lipgloss.Color("#87CEEB")). // Sky blue
 				Italic(true)
 
+	// SourceStyle is used for source file and line information
+	SourceStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#808080"))
+
+	// ErrorStyle is used for documents that failed to parse
+	ErrorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF0000")).
+			Bold(true)
+
+	// WarningStyle is used for analysis warnings
+	WarningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFA500"))
+
+	// StatusStyle is used for status messages such as export results
+	StatusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#00FF00"))
+
+	// OtherStyle is used for documents that are not Kubernetes objects
+	OtherStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#808080")).
+			Italic(true)
+
+	// NamespaceStyle is used for namespace headings in the graph view
+	NamespaceStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#9370DB")).
+			Bold(true)
+
 	// GraphNodeStyle is used for graph nodes
 	GraphNodeStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FFFFFF")).
@@ -25,14 +52,38 @@ var (
 
 	// ResourceStyles defines color coding for different resource types
 	ResourceStyles = map[string]lipgloss.Style{
-		"Service":     lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00")), // Green
-		"Deployment":  lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")), // Magenta
-		"Pod":         lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF")), // Cyan
-		"ConfigMap":   lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF00")), // Yellow
-		"Secret":      lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")), // Red
-		"StatefulSet": lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500")), // Orange
-		"Ingress":     lipgloss.NewStyle().Foreground(lipgloss.Color("#FF69B4")), // Pink
-		"Namespace":   lipgloss.NewStyle().Foreground(lipgloss.Color("#9370DB")), // Purple
-		"HPA":         lipgloss.NewStyle().Foreground(lipgloss.Color("#98FB98")), // Pale green
+		"Service":               lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00")), // Green
+		"Deployment":            lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")), // Magenta
+		"Pod":                   lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF")), // Cyan
+		"ConfigMap":             lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF00")), // Yellow
+		"Secret":                lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")), // Red
+		"StatefulSet":           lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500")), // Orange
+		"DaemonSet":             lipgloss.NewStyle().Foreground(lipgloss.Color("#DA70D6")), // Orchid
+		"ReplicaSet":            lipgloss.NewStyle().Foreground(lipgloss.Color("#EE82EE")), // Violet
+		"Job":                   lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF")), // Dodger blue
+		"CronJob":               lipgloss.NewStyle().Foreground(lipgloss.Color("#6495ED")), // Cornflower blue
+		"ReplicationController": lipgloss.NewStyle().Foreground(lipgloss.Color("#DDA0DD")), // Plum
+		"ServiceAccount":        lipgloss.NewStyle().Foreground(lipgloss.Color("#20B2AA")), // Light sea green
+		"PersistentVolumeClaim": lipgloss.NewStyle().Foreground(lipgloss.Color("#D2B48C")), // Tan
+		"PersistentVolume":      lipgloss.NewStyle().Foreground(lipgloss.Color("#CD853F")), // Peru
+		"StorageClass":          lipgloss.NewStyle().Foreground(lipgloss.Color("#BC8F8F")), // Rosy brown
+		"Role":                  lipgloss.NewStyle().Foreground(lipgloss.Color("#F0E68C")), // Khaki
+		"ClusterRole":           lipgloss.NewStyle().Foreground(lipgloss.Color("#BDB76B")), // Dark khaki
+		"RoleBinding":           lipgloss.NewStyle().Foreground(lipgloss.Color("#FFDAB9")), // Peach
+		"ClusterRoleBinding":    lipgloss.NewStyle().Foreground(lipgloss.Color("#F4A460")), // Sandy brown
+		"Ingress":               lipgloss.NewStyle().Foreground(lipgloss.Color("#FF69B4")), // Pink
+		"IngressClass":          lipgloss.NewStyle().Foreground(lipgloss.Color("#DB7093")), // Pale violet red
+		"GatewayClass":          lipgloss.NewStyle().Foreground(lipgloss.Color("#4682B4")), // Steel blue
+		"Gateway":               lipgloss.NewStyle().Foreground(lipgloss.Color("#00BFFF")), // Deep sky blue
+		"HTTPRoute":             lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB6C1")), // Light pink
+		"GRPCRoute":             lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA07A")), // Light salmon
+		"TCPRoute":              lipgloss.NewStyle().Foreground(lipgloss.Color("#E9967A")), // Dark salmon
+		"TLSRoute":              lipgloss.NewStyle().Foreground(lipgloss.Color("#FA8072")), // Salmon
+		"UDPRoute":              lipgloss.NewStyle().Foreground(lipgloss.Color("#F08080")), // Light coral
+		"ReferenceGrant":        lipgloss.NewStyle().Foreground(lipgloss.Color("#B0C4DE")), // Light steel blue
+		"NetworkPolicy":         lipgloss.NewStyle().Foreground(lipgloss.Color("#3CB371")), // Medium sea green
+		"PodDisruptionBudget":   lipgloss.NewStyle().Foreground(lipgloss.Color("#66CDAA")), // Medium aquamarine
+		"Namespace":             lipgloss.NewStyle().Foreground(lipgloss.Color("#9370DB")), // Purple
+		"HPA":                   lipgloss.NewStyle().Foreground(lipgloss.Color("#98FB98")), // Pale green
 	}
 )
@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"k8spreview/pkg/k8s"
+)
+
+// treeLine is a visible line of the ownership tree
+type treeLine struct {
+	node   *k8s.OwnerNode
+	prefix string // Branch drawing in front of the node
+	parent int    // Index of the parent line, -1 for roots
+}
+
+// treeLines flattens the expanded part of the ownership tree
+func (m Model) treeLines() []treeLine {
+	var lines []treeLine
+	var walk func(nodes []*k8s.OwnerNode, indent string, parent int)
+	walk = func(nodes []*k8s.OwnerNode, indent string, parent int) {
+		for i, n := range nodes {
+			last := i == len(nodes)-1
+			branch, next := "├─ ", "│  "
+			if last {
+				branch, next = "└─ ", "   "
+			}
+			if parent < 0 {
+				branch, next = "", ""
+			}
+			lines = append(lines, treeLine{node: n, prefix: indent + branch, parent: parent})
+			if !m.collapsed[n.Node] {
+				walk(n.Owned, indent+next, len(lines)-1)
+			}
+		}
+	}
+	walk(m.ownerTree, "", -1)
+	return lines
+}
+
+// updateTree handles the keys of the tree view: up and down move the
+// cursor, enter or space toggle the owned objects, left collapses or goes
+// to the owner and right expands
+func (m Model) updateTree(msg tea.KeyMsg) Model {
+	lines := m.treeLines()
+	if len(lines) == 0 {
+		return m
+	}
+	cur := lines[m.treeCursor]
+	switch msg.String() {
+	case "up", "k":
+		m.treeCursor = max(m.treeCursor-1, 0)
+	case "down", "j":
+		m.treeCursor = min(m.treeCursor+1, len(lines)-1)
+	case "home":
+		m.treeCursor = 0
+	case "end":
+		m.treeCursor = len(lines) - 1
+	case "enter", " ":
+		if len(cur.node.Owned) > 0 {
+			m.collapsed[cur.node.Node] = !m.collapsed[cur.node.Node]
+		}
+	case "left", "h":
+		if len(cur.node.Owned) > 0 && !m.collapsed[cur.node.Node] {
+			m.collapsed[cur.node.Node] = true
+		} else if cur.parent >= 0 {
+			m.treeCursor = cur.parent
+		}
+	case "right", "l":
+		delete(m.collapsed, cur.node.Node)
+	}
+	m.setTreeContent()
+	return m
+}
+
+// focusTree places the tree cursor on n, if it is in the tree
+func (m *Model) focusTree(n k8s.Node) {
+	for i, line := range m.treeLines() {
+		if line.node.Node == n {
+			m.treeCursor = i
+			return
+		}
+	}
+}
+
+// setTreeContent renders the tree view and scrolls the cursor into view
+func (m *Model) setTreeContent() {
+	const header = 2 // Title and blank line
+	m.viewport.SetContent(m.treeContent())
+	height := m.viewport.Height - m.viewport.Style.GetVerticalFrameSize()
+	switch line := m.treeCursor + header; {
+	case m.treeCursor == 0:
+		m.viewport.GotoTop()
+	case line < m.viewport.YOffset:
+		m.viewport.SetYOffset(line)
+	case line >= m.viewport.YOffset+height:
+		m.viewport.SetYOffset(line - height + 1)
+	}
+}
+
+// treeContent renders the ownership tree with the cursor line highlighted
+func (m Model) treeContent() string {
+	var sb strings.Builder
+	sb.WriteString(TitleStyle.Render("Ownership Tree") + "\n\n")
+
+	lines := m.treeLines()
+	if len(lines) == 0 {
+		sb.WriteString(SourceStyle.Render("No resources loaded") + "\n")
+	}
+	for i, line := range lines {
+		n := line.node
+		marker := "  "
+		switch {
+		case len(n.Owned) > 0 && m.collapsed[n.Node]:
+			marker = "▸ "
+		case len(n.Owned) > 0:
+			marker = "▾ "
+		}
+		cursor := "  "
+		if i == m.treeCursor {
+			cursor = StatusStyle.Render("> ")
+		}
+		sb.WriteString(cursor + SourceStyle.Render(line.prefix) + marker + ResourceStyles[n.Node.Kind].Render(n.Node.String()))
+		if n.Node.Namespace != "" {
+			sb.WriteString(SourceStyle.Render("  in " + n.Node.Namespace))
+		}
+		if len(n.Owned) > 0 && m.collapsed[n.Node] {
+			sb.WriteString(SourceStyle.Render(fmt.Sprintf("  (%d owned)", countOwned(n))))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n↑/↓ move  enter expand/collapse  ←/→ collapse/expand  q back to list")
+	return sb.String()
+}
+
+// countOwned returns the number of objects n owns, directly or indirectly
+func countOwned(n *k8s.OwnerNode) int {
+	count := len(n.Owned)
+	for _, c := range n.Owned {
+		count += countOwned(c)
+	}
+	return count
+}